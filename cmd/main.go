@@ -7,16 +7,23 @@ import (
 	"fio_finder/internal/config"
 	"fio_finder/internal/delivery/graphql"
 	myHttp "fio_finder/internal/delivery/http"
+	"fio_finder/internal/enrichment"
+	"fio_finder/internal/enrichment/api_enricher"
+	"fio_finder/internal/enrichment/breaker_enricher"
+	"fio_finder/internal/enrichment/cached_enricher"
+	"fio_finder/internal/enrichment/offline_enricher"
 	"fio_finder/internal/repository"
 	"fio_finder/internal/repository/postgres_repository"
 	"fio_finder/internal/server"
 	"fio_finder/internal/service"
 	"fio_finder/internal/service/serviceImpl"
+	"fio_finder/pkg/actor"
 	"fio_finder/pkg/cache"
 	redisCache "fio_finder/pkg/cache/redis"
 	"fio_finder/pkg/database"
 	"fio_finder/pkg/kafka"
 	"fio_finder/pkg/logger"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -37,10 +44,11 @@ type appRepositoryFields struct {
 	personRepository repository.PersonRepository
 }
 
-func (a *App) initServices(r *appRepositoryFields, c *cache.Cache, producer *kafka.Producer, consumer *kafka.Consumer) *service.Services {
+func (a *App) initServices(r *appRepositoryFields, c *cache.Cache, enricher enrichment.Enricher, health enrichment.HealthReporter, producer *kafka.Producer, consumer *kafka.Consumer) *service.Services {
 	f := &service.Services{
-		Person: serviceImpl.NewPersonServiceImplementation(r.personRepository, a.logger, *c, a.config.Redis.Ttl),
-		Kafka:  serviceImpl.NewKafkaSerivce(producer, consumer, r.personRepository),
+		Person:     serviceImpl.NewPersonServiceImplementation(r.personRepository, enricher, a.logger, *c, a.config.Redis.Ttl, a.config.Enrichment.GenderRules, a.config.Persons),
+		Enrichment: serviceImpl.NewEnrichmentServiceImplementation(r.personRepository, enricher, health, a.logger, a.config.Enrichment),
+		Kafka:      serviceImpl.NewKafkaSerivce(producer, consumer, r.personRepository, a.config.Kafka.BatchSize, a.config.Kafka.BatchWait),
 	}
 
 	return f
@@ -51,12 +59,50 @@ func (a *App) initPostgresRepositories(db *sql.DB) *appRepositoryFields {
 		return nil
 	}
 	f := &appRepositoryFields{
-		personRepository: postgres_repository.CreatePersonPostgresRepository(db),
+		personRepository: postgres_repository.CreatePersonPostgresRepository(db, a.config.Persons.NaturalKey),
 	}
 
 	return f
 }
 
+// initEnricher also returns the provider health reporter of the backend, or
+// nil if the backend has none.
+func (a *App) initEnricher(c cache.Cache) (enrichment.Enricher, enrichment.HealthReporter, error) {
+	switch a.config.Enrichment.Backend {
+	case config.EnrichmentBackendApi:
+		// Only provider answers are cached. The breaker stays outside the cache
+		// so degraded results are never stored under the provider keys.
+		var enricher enrichment.Enricher = cached_enricher.NewCachedEnricher(
+			api_enricher.NewApiEnricher(a.config.Enrichment), c, a.config.Enrichment.CacheTtl)
+		var health enrichment.HealthReporter
+		if a.config.Enrichment.Breaker.Threshold > 0 {
+			secondary, err := a.initSecondaryEnricher()
+			if err != nil {
+				return nil, nil, err
+			}
+			breaker := breaker_enricher.NewBreakerEnricher(enricher, secondary, a.config.Enrichment.Breaker)
+			enricher, health = breaker, breaker
+		}
+		return enricher, health, nil
+	case config.EnrichmentBackendOffline:
+		enricher, err := offline_enricher.NewOfflineEnricher(a.config.Enrichment.DatasetPath)
+		return enricher, nil, err
+	default:
+		return nil, nil, fmt.Errorf("unknown enrichment backend: %s", a.config.Enrichment.Backend)
+	}
+}
+
+func (a *App) initSecondaryEnricher() (enrichment.Enricher, error) {
+	switch a.config.Enrichment.Breaker.Policy {
+	case config.DegradedPolicySecondary:
+		return offline_enricher.NewOfflineEnricher(a.config.Enrichment.DatasetPath)
+	case config.DegradedPolicyReject, config.DegradedPolicySaveUnenriched:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown enrichment degraded policy: %s", a.config.Enrichment.Breaker.Policy)
+	}
+}
+
 func (a *App) Init() {
 	cfg, err := config.Init()
 	if err != nil {
@@ -90,11 +136,16 @@ func (a *App) Init() {
 		a.logger.Fatalf("error creating Kafka consumer: %v", err)
 	}
 
+	enricher, health, err := a.initEnricher(memCache)
+	if err != nil {
+		a.logger.Fatalf("error enricher init: %v", err)
+	}
+
 	a.repositories = a.initPostgresRepositories(db)
-	a.services = a.initServices(a.repositories, &memCache, producer, consumer)
+	a.services = a.initServices(a.repositories, &memCache, enricher, health, producer, consumer)
 
 	if a.config.Handler == "rest" {
-		handler := myHttp.NewHandler(a.services, a.logger)
+		handler := myHttp.NewHandler(a.services, a.logger, a.config.Enrichment.Async.Enabled)
 		a.server = server.NewServer(cfg, handler.Init())
 	} else if a.config.Handler == "graphql" {
 		handler := graphql.NewHandler(a.services, a.logger)
@@ -115,11 +166,17 @@ func main() {
 
 	a.logger.Println("server started ", a.config.Server.Port)
 
+	workerCtx, stopWorkers := context.WithCancel(actor.WithActor(context.Background(), actor.Enrichment))
+	go a.services.Enrichment.RunEnrichmentWorkers(workerCtx)
+	go a.services.Enrichment.RunReEnrichment(workerCtx)
+
 	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	<-quit
+	stopWorkers()
+	a.services.Kafka.Close()
 
 	const timeout = 5 * time.Second
 
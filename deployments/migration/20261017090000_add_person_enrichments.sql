@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create table service.person_enrichments (
+    person_id int not null references service.persons (id) on delete cascade,
+    field text not null,
+    provider text not null,
+    probability double precision,
+    sample_count bigint not null,
+    enriched_at timestamptz not null default now(),
+    primary key (person_id, field)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists service.person_enrichments;
+-- +goose StatementEnd
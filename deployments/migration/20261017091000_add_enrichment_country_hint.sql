@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table service.person_enrichments
+    add column country_hint text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table service.person_enrichments
+    drop column if exists country_hint;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+create table service.person_nationalities (
+    person_id int not null references service.persons (id) on delete cascade,
+    country_id text not null,
+    probability double precision not null,
+    primary key (person_id, country_id)
+);
+create index person_nationalities_probability_idx on service.person_nationalities (country_id, probability);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists service.person_nationalities;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table service.persons
+    alter column age drop not null,
+    alter column gender drop not null,
+    alter column nationality drop not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+update service.persons set age = 0 where age is null;
+update service.persons set nationality = '' where nationality is null;
+-- Unknown genders have no valid value to fall back to and must be resolved
+-- before the constraint can be restored.
+alter table service.persons
+    alter column age set not null,
+    alter column gender set not null,
+    alter column nationality set not null;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table service.persons
+    add column status text not null default 'manual',
+    add column country_hint text not null default '',
+    add column enrichment_claimed_at timestamptz;
+
+update service.persons p set status = 'enriched'
+    where exists (select 1 from service.person_enrichments e where e.person_id = p.id and e.provider <> 'manual');
+
+create index persons_status_idx on service.persons (status, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists service.persons_status_idx;
+alter table service.persons
+    drop column if exists enrichment_claimed_at,
+    drop column if exists country_hint,
+    drop column if exists status;
+-- +goose StatementEnd
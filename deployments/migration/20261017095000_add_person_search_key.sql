@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table service.persons add column search_key text not null default '';
+
+-- Mirrors normalize.SearchKey: case-folded ICAO transliteration of
+-- "surname name patronymic" with whitespace collapsed. concat_ws skips a
+-- null patronymic instead of nulling the whole key.
+update service.persons set search_key = translate(
+    replace(replace(replace(replace(replace(replace(replace(replace(replace(
+        lower(trim(regexp_replace(concat_ws(' ', surname, name, patronymic), '\s+', ' ', 'g'))),
+        'щ', 'shch'), 'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'), 'ъ', 'ie'), 'ю', 'iu'), 'я', 'ia'),
+    'абвгдеёзийклмнопрстуфыэәғқңөұүһіь',
+    'abvgdeeziiklmnoprstufyeagqnouuhi');
+
+create index persons_search_key_idx on service.persons (search_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists service.persons_search_key_idx;
+alter table service.persons drop column if exists search_key;
+-- +goose StatementEnd
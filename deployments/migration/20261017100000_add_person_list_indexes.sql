@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create index persons_name_sort_idx on service.persons (name, id);
+create index persons_surname_sort_idx on service.persons (surname, id);
+create index persons_age_sort_idx on service.persons (age, id);
+create index persons_name_prefix_idx on service.persons (lower(name) text_pattern_ops);
+create index persons_surname_prefix_idx on service.persons (lower(surname) text_pattern_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists service.persons_surname_prefix_idx;
+drop index if exists service.persons_name_prefix_idx;
+drop index if exists service.persons_age_sort_idx;
+drop index if exists service.persons_surname_sort_idx;
+drop index if exists service.persons_name_sort_idx;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+create extension if not exists pg_trgm;
+
+create index persons_search_key_trgm_idx on service.persons using gin (search_key gin_trgm_ops);
+create index persons_search_key_tsv_idx on service.persons using gin (to_tsvector('simple', search_key));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists service.persons_search_key_tsv_idx;
+drop index if exists service.persons_search_key_trgm_idx;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table service.persons add column deleted_at timestamptz;
+
+create index persons_deleted_at_idx on service.persons (deleted_at) where deleted_at is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists service.persons_deleted_at_idx;
+delete from service.persons where deleted_at is not null;
+alter table service.persons drop column if exists deleted_at;
+-- +goose StatementEnd
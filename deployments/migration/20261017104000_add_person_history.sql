@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The history has no foreign key on purpose: it outlives purged persons.
+create table if not exists service.person_history (
+    id         bigserial primary key,
+    person_id  int         not null,
+    action     text        not null,
+    actor      text        not null,
+    version    bigint      not null,
+    changed_at timestamptz not null default now(),
+    old_values jsonb,
+    new_values jsonb
+);
+
+create index if not exists person_history_person_id_idx on service.person_history (person_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists service.person_history;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table service.persons add column external_id text not null default '';
+alter table service.persons add column natural_key text;
+
+-- Mirrors normalize.Key.
+create function pg_temp.name_key(value text) returns text as $$
+    select translate(
+        replace(replace(replace(replace(replace(replace(replace(replace(replace(
+            lower(trim(regexp_replace(coalesce(value, ''), '\s+', ' ', 'g'))),
+            'щ', 'shch'), 'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'), 'ъ', 'ie'), 'ю', 'iu'), 'я', 'ia'),
+        'абвгдеёзийклмнопрстуфыэәғқңөұүһіь',
+        'abvgdeeziiklmnoprstufyeagqnouuhi');
+$$ language sql immutable;
+
+-- Existing persons get the key of the default PERSON_NATURAL_KEY, that is
+-- surname, name, patronymic and external id; deployments configured with
+-- other parts have to recompute these keys. Where live persons already
+-- duplicate each other only the oldest one gets the key, the others keep
+-- none and have to be merged by hand: list them with
+--   select * from service.persons where natural_key is null and deleted_at is null;
+-- Deleted persons get the key as well, so restoring a duplicate conflicts.
+with keys as (
+    select id, deleted_at, concat_ws('|', pg_temp.name_key(surname), pg_temp.name_key(name),
+        pg_temp.name_key(patronymic), trim(external_id)) as natural_key
+    from service.persons
+), ranked as (
+    select id, natural_key,
+        row_number() over (partition by natural_key, deleted_at is null order by id) as rank,
+        deleted_at is null as live
+    from keys
+)
+update service.persons p set natural_key = ranked.natural_key
+from ranked
+where p.id = ranked.id and (not ranked.live or ranked.rank = 1);
+
+create unique index persons_natural_key_idx on service.persons (natural_key) where deleted_at is null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists service.persons_natural_key_idx;
+alter table service.persons drop column if exists natural_key;
+alter table service.persons drop column if exists external_id;
+-- +goose StatementEnd
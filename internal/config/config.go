@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -15,15 +16,91 @@ const (
 	defaultServerRWTimeout          = 10 * time.Second
 	defaultServerMaxHeaderMegabytes = 1
 	TTLCache                        = 10 * time.Minute
+
+	defaultAgifyURL              = "https://api.agify.io/"
+	defaultGenderizeURL          = "https://api.genderize.io/"
+	defaultNationalizeURL        = "https://api.nationalize.io/"
+	defaultEnrichmentHTTPTimeout = 10 * time.Second
+	defaultProviderTimeout       = 3 * time.Second
+	defaultEnrichmentCacheTtl    = 24 * time.Hour
+	defaultQuotaReserve          = 50
+	defaultQuotaMaxWait          = 5 * time.Second
+	defaultBreakerThreshold      = 5
+	defaultBreakerOpenTimeout    = 30 * time.Second
+	defaultKafkaBatchSize        = 10
+	defaultKafkaBatchWait        = 500 * time.Millisecond
+
+	defaultEnrichmentWorkers      = 4
+	defaultEnrichmentPollInterval = time.Second
+	defaultEnrichmentLease        = time.Minute
+
+	defaultDeletedPersonRetention = 30 * 24 * time.Hour
+	defaultNaturalKey             = NaturalKeySurname + "," + NaturalKeyName + "," + NaturalKeyPatronymic + "," + NaturalKeyExternalId
+
+	defaultReEnrichmentInterval  = time.Hour
+	defaultReEnrichmentMaxAge    = 30 * 24 * time.Hour
+	defaultReEnrichmentRate      = 60
+	defaultReEnrichmentBatchSize = 100
+)
+
+const (
+	EnrichmentBackendApi     = "api"
+	EnrichmentBackendOffline = "offline"
+)
+
+// Degraded policies tell what to do with a field while its provider circuit
+// is open.
+const (
+	DegradedPolicyReject         = "reject"
+	DegradedPolicySaveUnenriched = "save_unenriched"
+	DegradedPolicySecondary      = "secondary"
+)
+
+// Gender precedences tell which result wins when the rule-based gender
+// inference and the gender provider both have an answer.
+const (
+	GenderPrecedenceRules     = "rules"
+	GenderPrecedenceApi       = "api"
+	GenderPrecedenceConfident = "confident"
+)
+
+// Natural key parts name what the natural key of a person is built of.
+// Persons sharing a natural key are duplicates.
+const (
+	NaturalKeyName       = "name"
+	NaturalKeySurname    = "surname"
+	NaturalKeyPatronymic = "patronymic"
+	NaturalKeyExternalId = "external_id"
+)
+
+// Conflict policies tell what an upsert does with a person sharing the
+// natural key of an existing one.
+const (
+	ConflictPolicyKeep      = "keep"
+	ConflictPolicyOverwrite = "overwrite"
+	ConflictPolicyMerge     = "merge"
 )
 
 type Config struct {
-	Server   serverConfig
-	Database databaseConfig
-	Redis    RedisConfig
-	Kafka    KafkaConfig
-	Logger   LoggerConfig
-	Handler  string
+	Server     serverConfig
+	Database   databaseConfig
+	Redis      RedisConfig
+	Kafka      KafkaConfig
+	Logger     LoggerConfig
+	Enrichment EnrichmentConfig
+	Persons    PersonsConfig
+	Handler    string
+}
+
+// PersonsConfig controls how long deleted persons stay restorable before an
+// admin purge removes them, and how duplicates are told apart and resolved.
+// NaturalKey lists the natural key parts in order. The merge conflict policy
+// fills the fields of the existing person with the non-empty fields of the
+// new one, except for the status.
+type PersonsConfig struct {
+	DeletedRetention time.Duration
+	NaturalKey       []string
+	ConflictPolicy   string
 }
 
 type LoggerConfig struct {
@@ -52,7 +129,73 @@ type RedisConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers []string
+	Brokers   []string
+	BatchSize int
+	BatchWait time.Duration
+}
+
+type EnrichmentConfig struct {
+	Backend     string
+	DatasetPath string
+	Agify       EnrichmentProviderConfig
+	Genderize   EnrichmentProviderConfig
+	Nationalize EnrichmentProviderConfig
+	HTTPClient  *http.Client
+	CacheTtl    time.Duration
+	ReEnrich    ReEnrichmentConfig
+	// QuotaReserve is the remaining provider allowance below which requests
+	// are spread out until the quota resets. QuotaMaxWait caps the pause
+	// before a request fails with a quota error instead.
+	QuotaReserve int
+	QuotaMaxWait time.Duration
+	Breaker      BreakerConfig
+	Async        AsyncEnrichmentConfig
+	GenderRules  GenderRulesConfig
+}
+
+// GenderRulesConfig controls gender inference from patronymic and surname
+// endings. With the rules precedence the gender provider is only asked about
+// persons the rules know nothing about; the confident precedence keeps the
+// result with the higher probability.
+type GenderRulesConfig struct {
+	Enabled    bool
+	Precedence string
+}
+
+// AsyncEnrichmentConfig controls the worker pool that enriches persons saved
+// as pending. A claimed person is handed to another worker if it is still
+// pending once Lease has passed.
+type AsyncEnrichmentConfig struct {
+	Enabled      bool
+	Workers      int
+	PollInterval time.Duration
+	Lease        time.Duration
+}
+
+// BreakerConfig controls the per-provider circuit breakers. A circuit opens
+// after Threshold consecutive failures and lets a probe request through after
+// OpenTimeout. A non-positive Threshold disables the breakers. The secondary
+// policy falls back to the offline dataset at EnrichmentConfig.DatasetPath.
+type BreakerConfig struct {
+	Threshold   int
+	OpenTimeout time.Duration
+	Policy      string
+}
+
+// ReEnrichmentConfig controls the background job that refreshes missing or
+// outdated enrichment. Rate is the number of persons processed per minute.
+type ReEnrichmentConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	MaxAge    time.Duration
+	Rate      int
+	BatchSize int
+}
+
+type EnrichmentProviderConfig struct {
+	BaseURL string
+	ApiKey  string
+	Timeout time.Duration
 }
 
 func Init() (*Config, error) {
@@ -73,12 +216,117 @@ func Init() (*Config, error) {
 
 	brokerStr := os.Getenv("KAFKA_BROKERS")
 	brokers := strings.Split(brokerStr, ",")
+	batchSize, err := getEnvInt("KAFKA_BATCH_SIZE", defaultKafkaBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	batchWait, err := getEnvDuration("KAFKA_BATCH_WAIT", defaultKafkaBatchWait)
+	if err != nil {
+		return nil, err
+	}
 
 	logPath := os.Getenv("LOG_PATH")
 	level := os.Getenv("LOG_LEVEL")
 
 	handler := os.Getenv("HANDLER")
 
+	enrichmentTimeout, err := getEnvDuration("ENRICHMENT_HTTP_TIMEOUT", defaultEnrichmentHTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	agifyTimeout, err := getEnvDuration("AGIFY_TIMEOUT", defaultProviderTimeout)
+	if err != nil {
+		return nil, err
+	}
+	genderizeTimeout, err := getEnvDuration("GENDERIZE_TIMEOUT", defaultProviderTimeout)
+	if err != nil {
+		return nil, err
+	}
+	nationalizeTimeout, err := getEnvDuration("NATIONALIZE_TIMEOUT", defaultProviderTimeout)
+	if err != nil {
+		return nil, err
+	}
+	enrichmentCacheTtl, err := getEnvDuration("ENRICHMENT_CACHE_TTL", defaultEnrichmentCacheTtl)
+	if err != nil {
+		return nil, err
+	}
+	quotaReserve, err := getEnvInt("ENRICHMENT_QUOTA_RESERVE", defaultQuotaReserve)
+	if err != nil {
+		return nil, err
+	}
+	quotaMaxWait, err := getEnvDuration("ENRICHMENT_QUOTA_MAX_WAIT", defaultQuotaMaxWait)
+	if err != nil {
+		return nil, err
+	}
+	breakerThreshold, err := getEnvInt("ENRICHMENT_BREAKER_THRESHOLD", defaultBreakerThreshold)
+	if err != nil {
+		return nil, err
+	}
+	breakerOpenTimeout, err := getEnvDuration("ENRICHMENT_BREAKER_OPEN_TIMEOUT", defaultBreakerOpenTimeout)
+	if err != nil {
+		return nil, err
+	}
+	asyncEnrichment, err := getEnvBool("ENRICHMENT_ASYNC", false)
+	if err != nil {
+		return nil, err
+	}
+	enrichmentWorkers, err := getEnvInt("ENRICHMENT_WORKERS", defaultEnrichmentWorkers)
+	if err != nil {
+		return nil, err
+	}
+	enrichmentPollInterval, err := getEnvPositiveDuration("ENRICHMENT_POLL_INTERVAL", defaultEnrichmentPollInterval)
+	if err != nil {
+		return nil, err
+	}
+	enrichmentLease, err := getEnvDuration("ENRICHMENT_LEASE", defaultEnrichmentLease)
+	if err != nil {
+		return nil, err
+	}
+	deletedPersonRetention, err := getEnvDuration("DELETED_PERSON_RETENTION", defaultDeletedPersonRetention)
+	if err != nil {
+		return nil, err
+	}
+	naturalKey, err := getEnvNaturalKey("PERSON_NATURAL_KEY", defaultNaturalKey)
+	if err != nil {
+		return nil, err
+	}
+	conflictPolicy := getEnv("PERSON_CONFLICT_POLICY", ConflictPolicyMerge)
+	switch conflictPolicy {
+	case ConflictPolicyKeep, ConflictPolicyOverwrite, ConflictPolicyMerge:
+	default:
+		return nil, fmt.Errorf("invalid conflict policy in PERSON_CONFLICT_POLICY: %s", conflictPolicy)
+	}
+	genderRulesEnabled, err := getEnvBool("ENRICHMENT_GENDER_RULES", true)
+	if err != nil {
+		return nil, err
+	}
+	genderPrecedence := getEnv("ENRICHMENT_GENDER_PRECEDENCE", GenderPrecedenceConfident)
+	switch genderPrecedence {
+	case GenderPrecedenceRules, GenderPrecedenceApi, GenderPrecedenceConfident:
+	default:
+		return nil, fmt.Errorf("invalid gender precedence in ENRICHMENT_GENDER_PRECEDENCE: %s", genderPrecedence)
+	}
+	reEnrichmentEnabled, err := getEnvBool("REENRICHMENT_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+	reEnrichmentInterval, err := getEnvPositiveDuration("REENRICHMENT_INTERVAL", defaultReEnrichmentInterval)
+	if err != nil {
+		return nil, err
+	}
+	reEnrichmentMaxAge, err := getEnvDuration("REENRICHMENT_MAX_AGE", defaultReEnrichmentMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	reEnrichmentRate, err := getEnvInt("REENRICHMENT_RATE", defaultReEnrichmentRate)
+	if err != nil {
+		return nil, err
+	}
+	reEnrichmentBatchSize, err := getEnvInt("REENRICHMENT_BATCH_SIZE", defaultReEnrichmentBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		Server: serverConfig{
 			Port:               defaultServerPort,
@@ -98,12 +346,135 @@ func Init() (*Config, error) {
 			Ttl:      TTLCache,
 		},
 		Kafka: KafkaConfig{
-			Brokers: brokers,
+			Brokers:   brokers,
+			BatchSize: batchSize,
+			BatchWait: batchWait,
 		},
 		Logger: LoggerConfig{
 			Path:  logPath,
 			Level: level,
 		},
+		Enrichment: EnrichmentConfig{
+			Backend:     getEnv("ENRICHMENT_BACKEND", EnrichmentBackendApi),
+			DatasetPath: os.Getenv("ENRICHMENT_DATASET_PATH"),
+			Agify: EnrichmentProviderConfig{
+				BaseURL: getEnv("AGIFY_URL", defaultAgifyURL),
+				ApiKey:  os.Getenv("AGIFY_API_KEY"),
+				Timeout: agifyTimeout,
+			},
+			Genderize: EnrichmentProviderConfig{
+				BaseURL: getEnv("GENDERIZE_URL", defaultGenderizeURL),
+				ApiKey:  os.Getenv("GENDERIZE_API_KEY"),
+				Timeout: genderizeTimeout,
+			},
+			Nationalize: EnrichmentProviderConfig{
+				BaseURL: getEnv("NATIONALIZE_URL", defaultNationalizeURL),
+				ApiKey:  os.Getenv("NATIONALIZE_API_KEY"),
+				Timeout: nationalizeTimeout,
+			},
+			HTTPClient:   &http.Client{Timeout: enrichmentTimeout},
+			CacheTtl:     enrichmentCacheTtl,
+			QuotaReserve: quotaReserve,
+			QuotaMaxWait: quotaMaxWait,
+			Breaker: BreakerConfig{
+				Threshold:   breakerThreshold,
+				OpenTimeout: breakerOpenTimeout,
+				Policy:      getEnv("ENRICHMENT_BREAKER_POLICY", DegradedPolicyReject),
+			},
+			Async: AsyncEnrichmentConfig{
+				Enabled:      asyncEnrichment,
+				Workers:      enrichmentWorkers,
+				PollInterval: enrichmentPollInterval,
+				Lease:        enrichmentLease,
+			},
+			GenderRules: GenderRulesConfig{
+				Enabled:    genderRulesEnabled,
+				Precedence: genderPrecedence,
+			},
+			ReEnrich: ReEnrichmentConfig{
+				Enabled:   reEnrichmentEnabled,
+				Interval:  reEnrichmentInterval,
+				MaxAge:    reEnrichmentMaxAge,
+				Rate:      reEnrichmentRate,
+				BatchSize: reEnrichmentBatchSize,
+			},
+		},
+		Persons: PersonsConfig{
+			DeletedRetention: deletedPersonRetention,
+			NaturalKey:       naturalKey,
+			ConflictPolicy:   conflictPolicy,
+		},
 		Handler: handler,
 	}, nil
 }
+
+func getEnv(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %s: %v", key, err)
+	}
+	return number, nil
+}
+
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid flag in %s: %v", key, err)
+	}
+	return flag, nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %v", key, err)
+	}
+	return duration, nil
+}
+
+// getEnvPositiveDuration is getEnvDuration for intervals that drive a ticker,
+// which must be positive.
+func getEnvPositiveDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	duration, err := getEnvDuration(key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid duration in %s: must be positive", key)
+	}
+	return duration, nil
+}
+
+func getEnvNaturalKey(key string, defaultValue string) ([]string, error) {
+	var parts []string
+	for _, part := range strings.Split(getEnv(key, defaultValue), ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case NaturalKeyName, NaturalKeySurname, NaturalKeyPatronymic, NaturalKeyExternalId:
+			parts = append(parts, part)
+		default:
+			return nil, fmt.Errorf("invalid natural key part in %s: %q", key, part)
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"fio_finder/internal/delivery/graphql/graph/model"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/repositoryErrors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func toGraphPerson(p *models.Person) *model.Person {
+	enrichments := make([]*model.FieldEnrichment, 0, len(p.Enrichments))
+	for _, e := range p.Enrichments {
+		enrichments = append(enrichments, &model.FieldEnrichment{
+			Field:       e.Field,
+			Provider:    e.Provider,
+			Probability: e.Probability,
+			Count:       int(e.Count),
+			CountryHint: e.CountryHint,
+			EnrichedAt:  e.EnrichedAt,
+		})
+	}
+	nationalities := make([]*model.CountryProbability, 0, len(p.NationalityCandidates))
+	for _, n := range p.NationalityCandidates {
+		nationalities = append(nationalities, &model.CountryProbability{
+			CountryID:   n.CountryId,
+			Probability: n.Probability,
+		})
+	}
+	var age *int
+	if p.Age != nil {
+		value := int(*p.Age)
+		age = &value
+	}
+	var gender *string
+	if p.Gender != nil {
+		value := string(*p.Gender)
+		gender = &value
+	}
+	return &model.Person{
+		ID:                    strconv.FormatUint(p.Id, 10),
+		Name:                  p.Name,
+		Surname:               p.Surname,
+		Patronymic:            p.Patronymic,
+		Age:                   age,
+		Gender:                gender,
+		Nationality:           p.Nationality,
+		Status:                string(p.Status),
+		Version:               int(p.Version),
+		Enrichments:           enrichments,
+		NationalityCandidates: nationalities,
+	}
+}
+
+func toGraphPersonList(l *models.PersonList) *model.PersonList {
+	persons := make([]*model.Person, 0, len(l.Persons))
+	for i := range l.Persons {
+		persons = append(persons, toGraphPerson(&l.Persons[i]))
+	}
+	return &model.PersonList{Persons: persons, TotalCount: int(l.Total)}
+}
+
+func toGraphPersonConnection(l *models.PersonList, paged bool) *model.PersonConnection {
+	edges := make([]*model.PersonEdge, 0, len(l.Persons))
+	for i := range l.Persons {
+		edges = append(edges, &model.PersonEdge{Node: toGraphPerson(&l.Persons[i]), Cursor: l.Cursors[i]})
+	}
+	pageInfo := &model.PageInfo{HasNextPage: l.NextCursor != "", HasPreviousPage: paged}
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+	return &model.PersonConnection{Edges: edges, PageInfo: pageInfo, TotalCount: int(l.Total)}
+}
+
+func fromGraphPersonListFilter(f *model.PersonListFilter) (models.PersonFilter, error) {
+	var filter models.PersonFilter
+	if f == nil {
+		return filter, nil
+	}
+	if (f.MinAge != nil && *f.MinAge < 0) || (f.MaxAge != nil && *f.MaxAge < 0) {
+		return filter, repositoryErrors.InvalidFilter
+	}
+
+	filter.MinNationalityProbability = f.MinNationalityProbability
+	filter.HasPatronymic = f.HasPatronymic
+	if f.MinAge != nil {
+		value := uint64(*f.MinAge)
+		filter.MinAge = &value
+	}
+	if f.MaxAge != nil {
+		value := uint64(*f.MaxAge)
+		filter.MaxAge = &value
+	}
+	if f.Status != nil {
+		filter.Status = models.PersonStatus(*f.Status)
+	}
+	if f.Nationality != nil {
+		filter.NationalityCandidate = strings.ToUpper(*f.Nationality)
+	}
+	if f.Gender != nil {
+		filter.Gender = models.PersonGender(*f.Gender)
+	}
+	if f.PrimaryNationality != nil {
+		filter.Nationality = strings.ToUpper(*f.PrimaryNationality)
+	}
+	if f.NamePrefix != nil {
+		filter.NamePrefix = *f.NamePrefix
+	}
+	if f.SurnamePrefix != nil {
+		filter.SurnamePrefix = *f.SurnamePrefix
+	}
+	return filter, nil
+}
+
+func toGraphPersonChange(c models.PersonChange) *model.PersonChange {
+	return &model.PersonChange{
+		Action:    string(c.Action),
+		Actor:     c.Actor,
+		Version:   int(c.Version),
+		ChangedAt: c.ChangedAt,
+		OldValues: c.OldValues,
+		NewValues: c.NewValues,
+	}
+}
+
+func toGraphReEnrichmentStatus(s models.ReEnrichmentStatus) *model.ReEnrichmentStatus {
+	status := &model.ReEnrichmentStatus{
+		Running:   s.Running,
+		Processed: int(s.Processed),
+		Enriched:  int(s.Enriched),
+		Failed:    int(s.Failed),
+	}
+	if !s.StartedAt.IsZero() {
+		status.StartedAt = timePtr(s.StartedAt)
+	}
+	if !s.FinishedAt.IsZero() {
+		status.FinishedAt = timePtr(s.FinishedAt)
+	}
+	return status
+}
+
+func toGraphProviderHealth(h models.ProviderHealth) *model.ProviderHealth {
+	health := &model.ProviderHealth{
+		Provider: h.Provider,
+		State:    h.State,
+		Failures: h.Failures,
+	}
+	if !h.OpenedAt.IsZero() {
+		health.OpenedAt = timePtr(h.OpenedAt)
+	}
+	return health
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
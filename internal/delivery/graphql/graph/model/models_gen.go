@@ -2,6 +2,31 @@
 
 package model
 
+import (
+	"time"
+)
+
+type CountryProbability struct {
+	CountryID   string  `json:"CountryId"`
+	Probability float64 `json:"Probability"`
+}
+
+type FieldEnrichment struct {
+	Field       string    `json:"Field"`
+	Provider    string    `json:"Provider"`
+	Probability *float64  `json:"Probability,omitempty"`
+	Count       int       `json:"Count"`
+	CountryHint string    `json:"CountryHint"`
+	EnrichedAt  time.Time `json:"EnrichedAt"`
+}
+
+type NewEnrichedPerson struct {
+	Name       string  `json:"Name"`
+	Surname    string  `json:"Surname"`
+	Patronymic *string `json:"Patronymic,omitempty"`
+	CountryID  *string `json:"CountryId,omitempty"`
+}
+
 type NewPerson struct {
 	Name        *string `json:"Name,omitempty"`
 	Surname     *string `json:"Surname,omitempty"`
@@ -11,12 +36,83 @@ type NewPerson struct {
 	Nationality *string `json:"Nationality,omitempty"`
 }
 
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
+}
+
 type Person struct {
-	ID          string `json:"Id"`
-	Name        string `json:"Name"`
-	Surname     string `json:"Surname"`
-	Patronymic  string `json:"Patronymic"`
-	Age         int    `json:"Age"`
-	Gender      string `json:"Gender"`
-	Nationality string `json:"Nationality"`
+	ID                    string                `json:"Id"`
+	Name                  string                `json:"Name"`
+	Surname               string                `json:"Surname"`
+	Patronymic            string                `json:"Patronymic"`
+	Age                   *int                  `json:"Age,omitempty"`
+	Gender                *string               `json:"Gender,omitempty"`
+	Nationality           *string               `json:"Nationality,omitempty"`
+	Status                string                `json:"Status"`
+	Version               int                   `json:"Version"`
+	Enrichments           []*FieldEnrichment    `json:"Enrichments"`
+	NationalityCandidates []*CountryProbability `json:"NationalityCandidates"`
+	History               []*PersonChange       `json:"History"`
+}
+
+type PersonChange struct {
+	Action    string                 `json:"Action"`
+	Actor     string                 `json:"Actor"`
+	Version   int                    `json:"Version"`
+	ChangedAt time.Time              `json:"ChangedAt"`
+	OldValues map[string]interface{} `json:"OldValues,omitempty"`
+	NewValues map[string]interface{} `json:"NewValues,omitempty"`
+}
+
+type PersonConnection struct {
+	Edges      []*PersonEdge `json:"edges"`
+	PageInfo   *PageInfo     `json:"pageInfo"`
+	TotalCount int           `json:"totalCount"`
+}
+
+type PersonEdge struct {
+	Node   *Person `json:"node"`
+	Cursor string  `json:"cursor"`
+}
+
+type PersonList struct {
+	Persons    []*Person `json:"Persons"`
+	TotalCount int       `json:"TotalCount"`
+}
+
+type PersonListFilter struct {
+	Status                    *string  `json:"Status,omitempty"`
+	Nationality               *string  `json:"Nationality,omitempty"`
+	MinNationalityProbability *float64 `json:"MinNationalityProbability,omitempty"`
+	MinAge                    *int     `json:"MinAge,omitempty"`
+	MaxAge                    *int     `json:"MaxAge,omitempty"`
+	Gender                    *string  `json:"Gender,omitempty"`
+	PrimaryNationality        *string  `json:"PrimaryNationality,omitempty"`
+	NamePrefix                *string  `json:"NamePrefix,omitempty"`
+	SurnamePrefix             *string  `json:"SurnamePrefix,omitempty"`
+	HasPatronymic             *bool    `json:"HasPatronymic,omitempty"`
+}
+
+type PersonMatch struct {
+	Person *Person `json:"Person"`
+	Score  float64 `json:"Score"`
+}
+
+type ProviderHealth struct {
+	Provider string     `json:"Provider"`
+	State    string     `json:"State"`
+	Failures int        `json:"Failures"`
+	OpenedAt *time.Time `json:"OpenedAt,omitempty"`
+}
+
+type ReEnrichmentStatus struct {
+	Running    bool       `json:"Running"`
+	StartedAt  *time.Time `json:"StartedAt,omitempty"`
+	FinishedAt *time.Time `json:"FinishedAt,omitempty"`
+	Processed  int        `json:"Processed"`
+	Enriched   int        `json:"Enriched"`
+	Failed     int        `json:"Failed"`
 }
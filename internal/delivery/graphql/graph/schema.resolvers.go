@@ -2,28 +2,55 @@ package graph
 
 // This file will be automatically regenerated based on the schema, any resolver implementations
 // will be copied through when generating and any unknown code will be moved to the end.
-// Code generated by github.com/99designs/gqlgen version v0.17.37
+// Code generated by github.com/99designs/gqlgen version v0.17.40
 
 import (
 	"context"
 	"fio_finder/internal/delivery/graphql/graph/model"
 	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/repositoryErrors"
 	"strconv"
+	"time"
 )
 
 // CreatePerson is the resolver for the createPerson field.
 func (r *mutationResolver) CreatePerson(ctx context.Context, input model.NewPerson) (*bool, error) {
-	err := r.Services.Person.Create(ctx, &models.Person{
+	person := &models.Person{
 		Name:        *input.Name,
 		Surname:     *input.Surname,
 		Patronymic:  *input.Patronymic,
-		Age:         uint64(*input.Age),
-		Gender:      models.PersonGender(*input.Gender),
-		Nationality: *input.Nationality,
-	})
+		Nationality: input.Nationality,
+	}
+	if input.Age != nil {
+		age := uint64(*input.Age)
+		person.Age = &age
+	}
+	if input.Gender != nil {
+		gender := models.PersonGender(*input.Gender)
+		person.Gender = &gender
+	}
+	err := r.Services.Person.Create(ctx, person)
 	return nil, err
 }
 
+// CreateEnrichedPerson is the resolver for the createEnrichedPerson field.
+func (r *mutationResolver) CreateEnrichedPerson(ctx context.Context, input model.NewEnrichedPerson) (*model.Person, error) {
+	person := &models.Person{
+		Name:    input.Name,
+		Surname: input.Surname,
+	}
+	if input.Patronymic != nil {
+		person.Patronymic = *input.Patronymic
+	}
+	if input.CountryID != nil {
+		person.CountryHint = *input.CountryID
+	}
+	if err := r.Services.Person.CreateWithEnrichment(ctx, person); err != nil {
+		return nil, err
+	}
+	return toGraphPerson(person), nil
+}
+
 // DeletePerson is the resolver for the deletePerson field.
 func (r *mutationResolver) DeletePerson(ctx context.Context, id string) (*bool, error) {
 	intId, err := strconv.Atoi(id)
@@ -34,8 +61,24 @@ func (r *mutationResolver) DeletePerson(ctx context.Context, id string) (*bool,
 	return nil, err
 }
 
+// RestorePerson is the resolver for the restorePerson field.
+func (r *mutationResolver) RestorePerson(ctx context.Context, id string) (*bool, error) {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	err = r.Services.Person.Restore(ctx, uint64(intId))
+	return nil, err
+}
+
+// PurgeDeletedPersons is the resolver for the purgeDeletedPersons field.
+func (r *mutationResolver) PurgeDeletedPersons(ctx context.Context) (int, error) {
+	count, err := r.Services.Person.Purge(ctx)
+	return int(count), err
+}
+
 // UpdatePerson is the resolver for the updatePerson field.
-func (r *mutationResolver) UpdatePerson(ctx context.Context, id string, input model.NewPerson) (*bool, error) {
+func (r *mutationResolver) UpdatePerson(ctx context.Context, id string, input model.NewPerson, expectedVersion *int) (*bool, error) {
 	intId, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, err
@@ -51,67 +94,177 @@ func (r *mutationResolver) UpdatePerson(ctx context.Context, id string, input mo
 	if *input.Patronymic != "" {
 		fields[models.PersonFieldPatronymic] = *input.Patronymic
 	}
-	if *input.Age != 0 {
+	if input.Age != nil {
 		fields[models.PersonFieldAge] = *input.Age
 	}
-	if *input.Gender != "" {
+	if input.Gender != nil {
 		fields[models.PersonFieldGender] = *input.Gender
 	}
-	if *input.Nationality != "" {
+	if input.Nationality != nil {
 		fields[models.PersonFieldNationality] = *input.Nationality
 	}
 
-	err = r.Services.Person.Update(ctx, uint64(intId), fields)
+	var version *uint64
+	if expectedVersion != nil {
+		if *expectedVersion < 0 {
+			return nil, repositoryErrors.InvalidField
+		}
+		value := uint64(*expectedVersion)
+		version = &value
+	}
+	_, err = r.Services.Person.Update(ctx, uint64(intId), fields, version)
 	return nil, err
 }
 
+// WarmEnrichmentCache is the resolver for the warmEnrichmentCache field.
+func (r *mutationResolver) WarmEnrichmentCache(ctx context.Context, names []string, countryID *string) (*bool, error) {
+	var country string
+	if countryID != nil {
+		country = *countryID
+	}
+	err := r.Services.Enrichment.WarmCache(ctx, names, country)
+	return nil, err
+}
+
+// History is the resolver for the History field.
+func (r *personResolver) History(ctx context.Context, obj *model.Person) ([]*model.PersonChange, error) {
+	intId, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := r.Services.Person.GetHistory(ctx, uint64(intId))
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*model.PersonChange, 0, len(changes))
+	for _, c := range changes {
+		history = append(history, toGraphPersonChange(c))
+	}
+	return history, nil
+}
+
 // GetPersonList is the resolver for the getPersonList field.
-func (r *queryResolver) GetPersonList(ctx context.Context) ([]*model.Person, error) {
-	p, err := r.Services.Person.GetList(ctx)
+func (r *queryResolver) GetPersonList(ctx context.Context, status *string, nationality *string, minNationalityProbability *float64, minAge *int, maxAge *int, gender *string, primaryNationality *string, namePrefix *string, surnamePrefix *string, hasPatronymic *bool, sort *string, order *string, limit *int, offset *int) (*model.PersonList, error) {
+	filter, err := fromGraphPersonListFilter(&model.PersonListFilter{
+		Status:                    status,
+		Nationality:               nationality,
+		MinNationalityProbability: minNationalityProbability,
+		MinAge:                    minAge,
+		MaxAge:                    maxAge,
+		Gender:                    gender,
+		PrimaryNationality:        primaryNationality,
+		NamePrefix:                namePrefix,
+		SurnamePrefix:             surnamePrefix,
+		HasPatronymic:             hasPatronymic,
+	})
 	if err != nil {
 		return nil, err
 	}
-	res := make([]*model.Person, 0)
-	for i := range p {
-		resI := &model.Person{
-			Name:        p[i].Name,
-			Surname:     p[i].Surname,
-			Patronymic:  p[i].Patronymic,
-			Age:         int(p[i].Age),
-			Gender:      string(p[i].Gender),
-			Nationality: p[i].Nationality,
-		}
-		res = append(res, resI)
+	if sort != nil {
+		filter.SortField = models.PersonSortField(*sort)
 	}
-	return res, nil
+	if order != nil {
+		filter.SortDirection = models.SortDirection(*order)
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+	if offset != nil {
+		filter.Offset = *offset
+	}
+
+	p, err := r.Services.Person.GetList(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return toGraphPersonList(p), nil
+}
+
+// PersonsConnection is the resolver for the personsConnection field.
+func (r *queryResolver) PersonsConnection(ctx context.Context, filter *model.PersonListFilter, sort *string, order *string, first *int, after *string) (*model.PersonConnection, error) {
+	listFilter, err := fromGraphPersonListFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if sort != nil {
+		listFilter.SortField = models.PersonSortField(*sort)
+	}
+	if order != nil {
+		listFilter.SortDirection = models.SortDirection(*order)
+	}
+	if first != nil {
+		listFilter.Limit = *first
+	}
+	if after != nil {
+		listFilter.After = *after
+	}
+
+	p, err := r.Services.Person.GetList(ctx, listFilter)
+	if err != nil {
+		return nil, err
+	}
+	return toGraphPersonConnection(p, after != nil && *after != ""), nil
 }
 
 // GetPerson is the resolver for the getPerson field.
-func (r *queryResolver) GetPerson(ctx context.Context, id string) (*model.Person, error) {
+func (r *queryResolver) GetPerson(ctx context.Context, id string, asOf *time.Time) (*model.Person, error) {
 	intId, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, err
 	}
-	p, err := r.Services.Person.Get(ctx, uint64(intId))
+	var p *models.Person
+	if asOf != nil {
+		p, err = r.Services.Person.GetAsOf(ctx, uint64(intId), *asOf)
+	} else {
+		p, err = r.Services.Person.Get(ctx, uint64(intId))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toGraphPerson(p), nil
+}
+
+// SearchPersons is the resolver for the searchPersons field.
+func (r *queryResolver) SearchPersons(ctx context.Context, query string, limit *int) ([]*model.PersonMatch, error) {
+	searchLimit := 0
+	if limit != nil {
+		searchLimit = *limit
+	}
+	matches, err := r.Services.Person.Search(ctx, query, searchLimit)
 	if err != nil {
 		return nil, err
 	}
-	res := model.Person{
-		Name:        p.Name,
-		Surname:     p.Surname,
-		Patronymic:  p.Patronymic,
-		Age:         int(p.Age),
-		Gender:      string(p.Gender),
-		Nationality: p.Nationality,
+	res := make([]*model.PersonMatch, 0, len(matches))
+	for i := range matches {
+		res = append(res, &model.PersonMatch{Person: toGraphPerson(&matches[i].Person), Score: matches[i].Score})
 	}
-	return &res, nil
+	return res, nil
+}
+
+// ReEnrichmentStatus is the resolver for the reEnrichmentStatus field.
+func (r *queryResolver) ReEnrichmentStatus(ctx context.Context) (*model.ReEnrichmentStatus, error) {
+	return toGraphReEnrichmentStatus(r.Services.Enrichment.ReEnrichmentStatus()), nil
+}
+
+// EnrichmentHealth is the resolver for the enrichmentHealth field.
+func (r *queryResolver) EnrichmentHealth(ctx context.Context) ([]*model.ProviderHealth, error) {
+	health := r.Services.Enrichment.ProviderHealth()
+	res := make([]*model.ProviderHealth, 0, len(health))
+	for _, h := range health {
+		res = append(res, toGraphProviderHealth(h))
+	}
+	return res, nil
 }
 
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Person returns PersonResolver implementation.
+func (r *Resolver) Person() PersonResolver { return &personResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
 type mutationResolver struct{ *Resolver }
+type personResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
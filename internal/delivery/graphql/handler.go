@@ -3,6 +3,7 @@ package graphql
 import (
 	graph2 "fio_finder/internal/delivery/graphql/graph"
 	"fio_finder/internal/service"
+	"fio_finder/pkg/actor"
 	"fio_finder/pkg/logger"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
@@ -22,8 +23,17 @@ func NewHandler(services *service.Services, logger *logger.Logger) *Handler {
 
 }
 
-func (h *Handler) Init() *handler.Server {
+func (h *Handler) Init() http.Handler {
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
 	http.Handle("/query", h.srv)
-	return h.srv
+	return withActor(h.srv)
+}
+
+// withActor attributes the changes a request makes to the actor named in
+// its header.
+func withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := actor.WithActor(r.Context(), actor.FromHeader(r.Header.Get(actor.Header)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
@@ -3,6 +3,7 @@ package http
 import (
 	v1 "fio_finder/internal/delivery/http/v1"
 	"fio_finder/internal/service"
+	"fio_finder/pkg/actor"
 	"fio_finder/pkg/logger"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -10,28 +11,40 @@ import (
 )
 
 type Handler struct {
-	services service.Services
-	logger   logger.Logger
+	services        service.Services
+	logger          logger.Logger
+	asyncEnrichment bool
 }
 
-func NewHandler(services *service.Services, logger *logger.Logger) *Handler {
+// NewHandler creates the REST handler. With asyncEnrichment the FIO consumer
+// saves persons as pending and leaves enrichment to the background workers.
+func NewHandler(services *service.Services, logger *logger.Logger, asyncEnrichment bool) *Handler {
 	return &Handler{
-		services: *services,
-		logger:   *logger,
+		services:        *services,
+		logger:          *logger,
+		asyncEnrichment: asyncEnrichment,
 	}
 }
 
 func (h *Handler) Init() *gin.Engine {
 	router := gin.Default()
+	router.Use(actorMiddleware)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 	h.initAPI(router)
 	return router
 }
 
 func (h *Handler) initAPI(router *gin.Engine) {
-	handlerV1 := v1.NewHandler(&h.services, &h.logger)
+	handlerV1 := v1.NewHandler(&h.services, &h.logger, h.asyncEnrichment)
 	api := router.Group("/api")
 	{
 		handlerV1.Init(api)
 	}
 }
+
+// actorMiddleware attributes the changes a request makes to the actor named
+// in its header.
+func actorMiddleware(ctx *gin.Context) {
+	ctx.Request = ctx.Request.WithContext(actor.WithActor(ctx.Request.Context(), actor.FromHeader(ctx.GetHeader(actor.Header))))
+	ctx.Next()
+}
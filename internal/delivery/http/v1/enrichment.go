@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+func (h *Handler) initEnrichmentRoutes(api *gin.RouterGroup) {
+	g := api.Group("/enrichment")
+	{
+		g.POST("/cache/warm", h.warmCache)
+		g.GET("/reenrichment", h.getReEnrichmentStatus)
+		g.GET("/health", h.getEnrichmentHealth)
+	}
+}
+
+type warmCacheInput struct {
+	Names     []string `json:"names"`
+	CountryId string   `json:"country_id"`
+}
+
+// @Summary		Warm enrichment cache
+// @Tags			Enrichment
+// @Description	Enrich the given first names and store the results in the enrichment cache
+// @ModuleID		warmCache
+// @Accept			json
+// @Produce		json
+// @Param			input	body		warmCacheInput	true	"names to warm"
+// @Success		200		{object}	Resposne
+// @Failure		400		{object}	Resposne
+// @Failure		500		{object}	Resposne
+// @Router			/enrichment/cache/warm [post]
+func (h *Handler) warmCache(ctx *gin.Context) {
+	var input warmCacheInput
+
+	data, _ := io.ReadAll(ctx.Request.Body)
+
+	if err := json.Unmarshal(data, &input); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input data format: "+err.Error())
+		return
+	}
+
+	if err := h.service.Enrichment.WarmCache(ctx.Request.Context(), input.Names, input.CountryId); err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Can't warm enrichment cache: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Resposne{"Enrichment cache was successfully warmed"})
+}
+
+// @Summary		Get re-enrichment status
+// @Tags			Enrichment
+// @Description	Get progress of the background re-enrichment job
+// @ModuleID		getReEnrichmentStatus
+// @Produce		json
+// @Success		200	{object}	models.ReEnrichmentStatus
+// @Router			/enrichment/reenrichment [get]
+func (h *Handler) getReEnrichmentStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.service.Enrichment.ReEnrichmentStatus())
+}
+
+// @Summary		Get enrichment providers health
+// @Tags			Enrichment
+// @Description	Get circuit breaker state of every enrichment provider
+// @ModuleID		getEnrichmentHealth
+// @Produce		json
+// @Success		200	{array}	models.ProviderHealth
+// @Router			/enrichment/health [get]
+func (h *Handler) getEnrichmentHealth(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.service.Enrichment.ProviderHealth())
+}
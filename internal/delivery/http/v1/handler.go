@@ -7,14 +7,16 @@ import (
 )
 
 type Handler struct {
-	service service.Services
-	logger  logger.Logger
+	service         service.Services
+	logger          logger.Logger
+	asyncEnrichment bool
 }
 
-func NewHandler(service *service.Services, logger *logger.Logger) *Handler {
+func NewHandler(service *service.Services, logger *logger.Logger, asyncEnrichment bool) *Handler {
 	return &Handler{
-		service: *service,
-		logger:  *logger,
+		service:         *service,
+		logger:          *logger,
+		asyncEnrichment: asyncEnrichment,
 	}
 }
 
@@ -23,6 +25,7 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 	{
 		go h.consumeMessages()
 		h.initPersonRoutes(v1)
+		h.initEnrichmentRoutes(v1)
 
 	}
 }
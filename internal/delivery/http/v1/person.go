@@ -3,11 +3,17 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fio_finder/internal/models"
+	"fio_finder/pkg/actor"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"fio_finder/pkg/errors/repositoryErrors"
 	"github.com/gin-gonic/gin"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // @host		localhost:8000
@@ -16,10 +22,16 @@ func (h *Handler) initPersonRoutes(api *gin.RouterGroup) {
 	g := api.Group("/person")
 	{
 		g.POST("/create", h.create)
+		g.POST("/create/enriched", h.createEnriched)
+		g.POST("/create/bulk", h.createBulk)
 		g.GET("/:id", h.get)
+		g.GET("/:id/history", h.getHistory)
 		g.DELETE("/:id", h.delete)
+		g.POST("/:id/restore", h.restore)
+		g.POST("/purge", h.purge)
 		g.PUT("/:id", h.update)
 		g.GET("/list", h.getList)
+		g.GET("/search", h.search)
 	}
 }
 
@@ -32,6 +44,7 @@ func (h *Handler) initPersonRoutes(api *gin.RouterGroup) {
 // @Param			struct	body		person.Person	true	"Person"
 // @Success		201		{object}	Resposne
 // @Failure		400		{object}	Resposne
+// @Failure		409		{object}	Resposne
 // @Failure		500		{object}	Resposne
 // @Router			/person/create [post]
 func (h *Handler) create(ctx *gin.Context) {
@@ -45,14 +58,18 @@ func (h *Handler) create(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.service.Person.Create(context.Background(), &models.Person{
+	if err := h.service.Person.Create(ctx.Request.Context(), &models.Person{
 		Name:        p.Name,
 		Surname:     p.Surname,
 		Patronymic:  p.Patronymic,
 		Age:         p.Age,
 		Gender:      p.Gender,
 		Nationality: p.Nationality,
-	}); err != nil {
+		ExternalId:  p.ExternalId,
+	}); errors.Is(err, repositoryErrors.ObjectAlreadyExists) {
+		newResponse(ctx, http.StatusConflict, "Can't create a person: "+err.Error())
+		return
+	} else if err != nil {
 		newResponse(ctx, http.StatusInternalServerError, "Can't create a person: "+err.Error())
 		return
 	}
@@ -60,16 +77,145 @@ func (h *Handler) create(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, Resposne{"The person was successfully created"})
 }
 
+type createBulkResponse struct {
+	Ids []uint64 `json:"ids"`
+}
+
+// @Summary		Create Persons in bulk
+// @Tags			Person
+// @Description	Create up to 10000 Persons at once, all or none
+// @ModuleID		createBulk
+// @Accept			json
+// @Produce		json
+// @Param			persons	body		[]models.Person	true	"persons"
+// @Success		201		{object}	createBulkResponse
+// @Failure		400		{object}	Resposne
+// @Failure		409		{object}	Resposne
+// @Failure		500		{object}	Resposne
+// @Router			/person/create/bulk [post]
+func (h *Handler) createBulk(ctx *gin.Context) {
+	var input []models.Person
+
+	data, _ := io.ReadAll(ctx.Request.Body)
+
+	if err := json.Unmarshal(data, &input); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input data format: "+err.Error())
+		return
+	}
+
+	persons := make([]models.Person, 0, len(input))
+	for _, p := range input {
+		persons = append(persons, models.Person{
+			Name:        p.Name,
+			Surname:     p.Surname,
+			Patronymic:  p.Patronymic,
+			Age:         p.Age,
+			Gender:      p.Gender,
+			Nationality: p.Nationality,
+			CountryHint: p.CountryHint,
+			ExternalId:  p.ExternalId,
+		})
+	}
+
+	ids, err := h.service.Person.CreateMany(ctx.Request.Context(), persons)
+	switch {
+	case errors.Is(err, repositoryErrors.MissingRequiredFields), errors.Is(err, repositoryErrors.TooManyObjects),
+		errors.Is(err, enrichmentErrors.InvalidCountryHint):
+		newResponse(ctx, http.StatusBadRequest, "Can't create persons: "+err.Error())
+		return
+	case errors.Is(err, repositoryErrors.ObjectAlreadyExists):
+		newResponse(ctx, http.StatusConflict, "Can't create persons: "+err.Error())
+		return
+	case err != nil:
+		newResponse(ctx, http.StatusInternalServerError, "Can't create persons: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createBulkResponse{Ids: ids})
+}
+
+type createEnrichedInput struct {
+	Name       string `json:"name"`
+	Surname    string `json:"surname"`
+	Patronymic string `json:"patronymic"`
+	CountryId  string `json:"country_id"`
+}
+
+// @Summary		Create enriched Person
+// @Tags			Person
+// @Description	Enrich age, gender and nationality of a new Person and save it
+// @ModuleID		createEnriched
+// @Accept			json
+// @Produce		json
+// @Param			input	body		createEnrichedInput	true	"person name"
+// @Success		201		{object}	models.Person
+// @Failure		400		{object}	Resposne
+// @Failure		409		{object}	Resposne
+// @Failure		429		{object}	Resposne
+// @Failure		502		{object}	Resposne
+// @Failure		503		{object}	Resposne
+// @Failure		500		{object}	Resposne
+// @Router			/person/create/enriched [post]
+func (h *Handler) createEnriched(ctx *gin.Context) {
+	var input createEnrichedInput
+
+	data, _ := io.ReadAll(ctx.Request.Body)
+
+	if err := json.Unmarshal(data, &input); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input data format: "+err.Error())
+		return
+	}
+
+	p := &models.Person{
+		Name:        input.Name,
+		Surname:     input.Surname,
+		Patronymic:  input.Patronymic,
+		CountryHint: input.CountryId,
+	}
+	if err := h.service.Person.CreateWithEnrichment(ctx.Request.Context(), p); err != nil {
+		var quotaErr *enrichmentErrors.QuotaError
+		if errors.As(err, &quotaErr) {
+			ctx.Header("Retry-After", strconv.Itoa(int(quotaErr.RetryAfter.Seconds())))
+		}
+		newResponse(ctx, enrichmentErrorStatus(err), "Can't create a person: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, p)
+}
+
+// enrichmentErrorStatus maps a CreateWithEnrichment failure to a response
+// status.
+func enrichmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositoryErrors.MissingRequiredFields), errors.Is(err, enrichmentErrors.InvalidCountryHint):
+		return http.StatusBadRequest
+	case errors.Is(err, repositoryErrors.ObjectAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, enrichmentErrors.QuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, enrichmentErrors.CircuitOpen):
+		return http.StatusServiceUnavailable
+	}
+	var providerErr *enrichmentErrors.ProviderError
+	if errors.As(err, &providerErr) {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
 // @Summary		Get Person by ID
 // @Tags			Person
-// @Description	Get Person by ID
+// @Description	Get Person by ID, optionally as it was at a past moment
 // @ModuleID		get
 // @Accept			json
 // @Produce		json
-// @Param			id	path		integer	true	"person id"
-// @Success		200	{object}	models.Person
-// @Failure		400	{object}	Resposne
-// @Failure		500	{object}	Resposne
+// @Param			id		path		integer	true	"person id"
+// @Param			as_of	query		string	false	"RFC 3339 time to rebuild the person at from its history"
+// @Success		200		{object}	models.Person
+// @Failure		400		{object}	Resposne
+// @Failure		404		{object}	Resposne
+// @Failure		500		{object}	Resposne
 // @Router			/person/{id} [get]
 func (h *Handler) get(ctx *gin.Context) {
 	param := ctx.Param("id")
@@ -78,15 +224,73 @@ func (h *Handler) get(ctx *gin.Context) {
 		newResponse(ctx, http.StatusBadRequest, "Incorrect person ID: "+err.Error())
 		return
 	}
-	p, err := h.service.Person.Get(context.Background(), uint64(id))
+	if asOf := ctx.Query("as_of"); asOf != "" {
+		h.getAsOf(ctx, uint64(id), asOf)
+		return
+	}
+
+	p, err := h.service.Person.Get(ctx.Request.Context(), uint64(id))
 	if err != nil {
 		newResponse(ctx, http.StatusInternalServerError, "Can't get a person: "+err.Error())
 		return
 	}
 
+	ctx.Header("ETag", personETag(p.Version))
 	ctx.JSON(http.StatusOK, p)
 }
 
+// getAsOf responds with the person as it was at asOf. There is no ETag, as
+// past versions cannot be updated.
+func (h *Handler) getAsOf(ctx *gin.Context, id uint64, asOf string) {
+	t, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect as_of time: "+err.Error())
+		return
+	}
+
+	p, err := h.service.Person.GetAsOf(ctx.Request.Context(), id, t)
+	if errors.Is(err, repositoryErrors.ObjectDoesNotExists) {
+		newResponse(ctx, http.StatusNotFound, "No person with this ID at this time")
+		return
+	} else if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Can't get a person: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p)
+}
+
+// @Summary		Get Person history
+// @Tags			Person
+// @Description	Get every recorded change of a Person, oldest first
+// @ModuleID		getHistory
+// @Accept			json
+// @Produce		json
+// @Param			id	path		integer	true	"person id"
+// @Success		200	{array}		models.PersonChange
+// @Failure		400	{object}	Resposne
+// @Failure		404	{object}	Resposne
+// @Failure		500	{object}	Resposne
+// @Router			/person/{id}/history [get]
+func (h *Handler) getHistory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect person ID: "+err.Error())
+		return
+	}
+
+	changes, err := h.service.Person.GetHistory(ctx.Request.Context(), uint64(id))
+	if errors.Is(err, repositoryErrors.ObjectDoesNotExists) {
+		newResponse(ctx, http.StatusNotFound, "No person with this ID")
+		return
+	} else if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Can't get the person history: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, changes)
+}
+
 // @Summary		Delete Person
 // @Tags			Person
 // @Description	Delete Person
@@ -107,7 +311,7 @@ func (h *Handler) delete(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.service.Person.Delete(context.Background(), uint64(id)); err != nil {
+	if err := h.service.Person.Delete(ctx.Request.Context(), uint64(id)); err != nil {
 		newResponse(ctx, http.StatusInternalServerError, "Can't delete a person:"+err.Error())
 		return
 	}
@@ -115,17 +319,78 @@ func (h *Handler) delete(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, Resposne{"Person was successfully deleted"})
 }
 
+// @Summary		Restore Person
+// @Tags			Person
+// @Description	Restore a deleted Person
+// @ModuleID		restore
+// @Accept			json
+// @Produce		json
+// @Param			id	path		integer	true	"person id"
+// @Success		200	{object}	Resposne
+// @Failure		400	{object}	Resposne
+// @Failure		404	{object}	Resposne
+// @Failure		409	{object}	Resposne
+// @Failure		500	{object}	Resposne
+// @Router			/person/{id}/restore [post]
+func (h *Handler) restore(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect person ID: "+err.Error())
+		return
+	}
+
+	err = h.service.Person.Restore(ctx.Request.Context(), uint64(id))
+	if errors.Is(err, repositoryErrors.ObjectDoesNotExists) {
+		newResponse(ctx, http.StatusNotFound, "No deleted person with this ID")
+		return
+	} else if errors.Is(err, repositoryErrors.ObjectAlreadyExists) {
+		newResponse(ctx, http.StatusConflict, "The person was created again meanwhile: "+err.Error())
+		return
+	} else if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Can't restore a person: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, Resposne{"Person was successfully restored"})
+}
+
+type purgeResponse struct {
+	Purged uint64 `json:"purged"`
+}
+
+// @Summary		Purge deleted Persons
+// @Tags			Person
+// @Description	Permanently remove persons deleted longer than the retention period ago
+// @ModuleID		purge
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	purgeResponse
+// @Failure		500	{object}	Resposne
+// @Router			/person/purge [post]
+func (h *Handler) purge(ctx *gin.Context) {
+	count, err := h.service.Person.Purge(ctx.Request.Context())
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Can't purge deleted persons: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, purgeResponse{Purged: count})
+}
+
 // @Summary		Update Person
 // @Tags			Person
 // @Description	Update Person
 // @Accept			json
 // @Produce		json
 // @ModuleID		update
-// @Param			person	body		models.Person	true	"person update fields"
-// @Param			id		path		integer			true	"person id"
-// @Success		200		{object}	Resposne
-// @Failure		400		{object}	Resposne
-// @Failure		500		{object}	Resposne
+// @Param			person		body		models.Person	true	"person update fields"
+// @Param			id			path		integer			true	"person id"
+// @Param			If-Match	header		string			false	"ETag of the person as last read"
+// @Success		200			{object}	Resposne
+// @Failure		400			{object}	Resposne
+// @Failure		409			{object}	Resposne
+// @Failure		412			{object}	Resposne
+// @Failure		500			{object}	Resposne
 // @Router			/person/{id} [put]
 func (h *Handler) update(ctx *gin.Context) {
 	var p models.Person
@@ -137,6 +402,12 @@ func (h *Handler) update(ctx *gin.Context) {
 		return
 	}
 
+	expectedVersion, err := parseIfMatch(ctx.GetHeader("If-Match"))
+	if err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect If-Match header: "+err.Error())
+		return
+	}
+
 	data, _ := io.ReadAll(ctx.Request.Body)
 
 	if err := json.Unmarshal(data, &p); err != nil {
@@ -155,52 +426,328 @@ func (h *Handler) update(ctx *gin.Context) {
 	if p.Patronymic != "" {
 		fields[models.PersonFieldPatronymic] = p.Patronymic
 	}
-	if p.Age != 0 {
-		fields[models.PersonFieldAge] = p.Age
+	if p.Age != nil {
+		fields[models.PersonFieldAge] = *p.Age
 	}
-	if p.Gender != "" {
-		fields[models.PersonFieldGender] = p.Gender
+	if p.Gender != nil {
+		fields[models.PersonFieldGender] = *p.Gender
 	}
-	if p.Nationality != "" {
-		fields[models.PersonFieldNationality] = p.Nationality
+	if p.Nationality != nil {
+		fields[models.PersonFieldNationality] = *p.Nationality
 	}
 
-	if err := h.service.Person.Update(context.Background(), uint64(id), fields); err != nil {
+	version, err := h.service.Person.Update(ctx.Request.Context(), uint64(id), fields, expectedVersion)
+	var conflictErr *repositoryErrors.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		ctx.Header("ETag", personETag(conflictErr.Actual))
+		newResponse(ctx, http.StatusPreconditionFailed, "Person was changed meanwhile: "+err.Error())
+		return
+	} else if errors.Is(err, repositoryErrors.ObjectAlreadyExists) {
+		newResponse(ctx, http.StatusConflict, "Can't update a person: "+err.Error())
+		return
+	} else if err != nil {
 		newResponse(ctx, http.StatusInternalServerError, "Can't update a person: "+err.Error())
 		return
 	}
 
+	ctx.Header("ETag", personETag(version))
 	ctx.JSON(http.StatusOK, Resposne{"Person was successfully updated"})
 }
 
+func personETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// parseIfMatch returns the person version required by an If-Match header.
+// A missing header or "*" does not require any version.
+func parseIfMatch(header string) (*uint64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, errors.New("a single strong entity tag is expected")
+	}
+	version, err := strconv.ParseUint(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+type personListQuery struct {
+	Status                    string   `form:"status"`
+	NationalityCandidate      string   `form:"nationality"`
+	MinNationalityProbability *float64 `form:"min_nationality_probability"`
+	MinAge                    *uint64  `form:"min_age"`
+	MaxAge                    *uint64  `form:"max_age"`
+	Gender                    string   `form:"gender"`
+	Nationality               string   `form:"primary_nationality"`
+	NamePrefix                string   `form:"name_prefix"`
+	SurnamePrefix             string   `form:"surname_prefix"`
+	HasPatronymic             *bool    `form:"has_patronymic"`
+	Sort                      string   `form:"sort"`
+	Order                     string   `form:"order"`
+	Limit                     int      `form:"limit"`
+	Offset                    int      `form:"offset"`
+	Cursor                    string   `form:"cursor"`
+}
+
+type personListResponse struct {
+	Persons []models.Person `json:"persons"`
+	Total   uint64          `json:"total"`
+	// NextCursor is passed as the cursor parameter to get the next page. It
+	// is omitted on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 // @Summary		Get Person List
 // @Tags			Person
 // @Description	Get Person List
 // @ModuleID		get
 // @Accept			json
 // @Produce		json
-// @Success		200	{object}	[]models.Person
-// @Failure		400	{object}	Resposne
-// @Failure		500	{object}	Resposne
+// @Param			status							query		string	false	"enrichment status"
+// @Param			nationality						query		string	false	"nationality candidate country id"
+// @Param			min_nationality_probability	query		number	false	"minimal probability of a nationality candidate"
+// @Param			min_age							query		integer	false	"minimal age"
+// @Param			max_age							query		integer	false	"maximal age"
+// @Param			gender							query		string	false	"gender"
+// @Param			primary_nationality				query		string	false	"top nationality country id"
+// @Param			name_prefix						query		string	false	"name prefix"
+// @Param			surname_prefix					query		string	false	"surname prefix"
+// @Param			has_patronymic					query		boolean	false	"whether the patronymic is set"
+// @Param			sort							query		string	false	"sort field: id, name, surname, patronymic, age, gender or nationality"
+// @Param			order							query		string	false	"sort direction: asc or desc"
+// @Param			limit							query		integer	false	"page size, 100 by default and 1000 at most"
+// @Param			offset							query		integer	false	"number of persons to skip"
+// @Param			cursor							query		string	false	"next_cursor of the previous page, not combinable with offset"
+// @Success		200								{object}	personListResponse
+// @Failure		400								{object}	Resposne
+// @Failure		500								{object}	Resposne
 // @Router			/person/list [get]
 func (h *Handler) getList(ctx *gin.Context) {
-	p, err := h.service.Person.GetList(context.Background())
-	if err != nil {
+	var query personListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect list parameters: "+err.Error())
+		return
+	}
+	filter := models.PersonFilter{
+		Status:                    models.PersonStatus(query.Status),
+		NationalityCandidate:      strings.ToUpper(query.NationalityCandidate),
+		MinNationalityProbability: query.MinNationalityProbability,
+		MinAge:                    query.MinAge,
+		MaxAge:                    query.MaxAge,
+		Gender:                    models.PersonGender(query.Gender),
+		Nationality:               strings.ToUpper(query.Nationality),
+		NamePrefix:                query.NamePrefix,
+		SurnamePrefix:             query.SurnamePrefix,
+		HasPatronymic:             query.HasPatronymic,
+		SortField:                 models.PersonSortField(query.Sort),
+		SortDirection:             models.SortDirection(query.Order),
+		Limit:                     query.Limit,
+		Offset:                    query.Offset,
+		After:                     query.Cursor,
+	}
+
+	p, err := h.service.Person.GetList(ctx.Request.Context(), filter)
+	if errors.Is(err, repositoryErrors.InvalidFilter) {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect list parameters: "+err.Error())
+		return
+	} else if err != nil {
 		newResponse(ctx, http.StatusInternalServerError, "Can't get a person list")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, p)
+	persons := p.Persons
+	if persons == nil {
+		persons = []models.Person{}
+	}
+	ctx.JSON(http.StatusOK, personListResponse{Persons: persons, Total: p.Total, NextCursor: p.NextCursor})
+}
+
+// @Summary		Search Persons
+// @Tags			Person
+// @Description	Find persons by partial or misspelled name, surname or patronymic, best matches first
+// @ModuleID		search
+// @Accept			json
+// @Produce		json
+// @Param			q		query		string	true	"search query"
+// @Param			limit	query		integer	false	"maximal number of matches, 20 by default and 100 at most"
+// @Success		200		{object}	[]models.PersonMatch
+// @Failure		400		{object}	Resposne
+// @Failure		500		{object}	Resposne
+// @Router			/person/search [get]
+func (h *Handler) search(ctx *gin.Context) {
+	limit := 0
+	if param := ctx.Query("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil {
+			newResponse(ctx, http.StatusBadRequest, "Incorrect limit: "+err.Error())
+			return
+		}
+	}
+
+	matches, err := h.service.Person.Search(ctx.Request.Context(), ctx.Query("q"), limit)
+	if errors.Is(err, repositoryErrors.MissingRequiredFields) || errors.Is(err, repositoryErrors.InvalidFilter) {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect search parameters: "+err.Error())
+		return
+	} else if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Can't search persons")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, matches)
 }
 
 func (h *Handler) consumeMessages() {
-	err := h.service.Kafka.ConsumeMessages("FIO", h.handleMessage)
+	handler := h.handleMessages
+	if h.asyncEnrichment {
+		handler = h.handlePendingMessages
+	}
+	err := h.service.Kafka.ConsumeBatches("FIO", handler)
 	if err != nil {
 		h.logger.Println(err)
 	}
 }
 
+// consumerContext attributes the changes made by the FIO consumer.
+func consumerContext() context.Context {
+	return actor.WithActor(context.Background(), actor.Kafka)
+}
+
+// handleMessages enriches a burst of messages with multi-name provider
+// requests instead of enriching every person on its own.
+func (h *Handler) handleMessages(messages []string) {
+	if len(messages) == 1 {
+		h.handleMessage(messages[0])
+		return
+	}
+	h.logger.Info("message batch received: " + strconv.Itoa(len(messages)))
+
+	persons := make([]models.Person, 0, len(messages))
+	accepted := make([]string, 0, len(messages))
+	for _, message := range messages {
+		var p models.Person
+		if err := json.Unmarshal([]byte(message), &p); err != nil {
+			h.sendFailed("invalid format: " + err.Error())
+			continue
+		}
+		if len(p.Name) == 0 || len(p.Surname) == 0 {
+			h.sendFailed("can't create a person: " + repositoryErrors.MissingRequiredFields.Error())
+			continue
+		}
+		person := models.Person{
+			Name:        p.Name,
+			Surname:     p.Surname,
+			Patronymic:  p.Patronymic,
+			CountryHint: p.CountryHint,
+			ExternalId:  p.ExternalId,
+		}
+
+		// Known persons are upserted as they are, like a single message, so
+		// no provider quota is spent on them.
+		known, err := h.service.Person.IsKnown(consumerContext(), &person)
+		if err != nil {
+			h.sendFailed("can't create a person: " + err.Error())
+			continue
+		}
+		if known {
+			if _, err := h.service.Person.Upsert(consumerContext(), &person); err != nil {
+				h.sendFailed("can't create a person: " + err.Error())
+			}
+			continue
+		}
+		persons = append(persons, person)
+		accepted = append(accepted, message)
+	}
+	if len(persons) == 0 {
+		return
+	}
+
+	if err := h.service.Person.BatchEnrich(consumerContext(), persons); err != nil {
+		// Fall back to one-by-one processing so every message gets its own
+		// outcome instead of the whole burst failing together.
+		// The quota, if that is what failed, is waited for once for the whole
+		// burst, so every message gets a single attempt afterwards.
+		h.logger.Error("batch enrichment failed, handling messages one by one: " + err.Error())
+		h.postpone(err)
+		for _, message := range accepted {
+			h.processMessage(message, 1)
+		}
+		return
+	}
+
+	h.createMany(persons)
+}
+
+// createMany saves persons of a message batch at once. If that fails, for
+// example because some are already known, they are upserted one by one so
+// every message gets its own outcome.
+func (h *Handler) createMany(persons []models.Person) {
+	if len(persons) == 0 {
+		return
+	}
+	_, err := h.service.Person.CreateMany(consumerContext(), persons)
+	if err == nil {
+		return
+	}
+	h.logger.Error("bulk create failed, upserting persons one by one: " + err.Error())
+
+	for i := range persons {
+		if _, err := h.service.Person.Upsert(consumerContext(), &persons[i]); err != nil {
+			h.sendFailed("can't create a person: " + err.Error())
+		}
+	}
+}
+
+// handlePendingMessages saves persons without waiting for enrichment, which
+// the background workers take care of.
+func (h *Handler) handlePendingMessages(messages []string) {
+	persons := make([]models.Person, 0, len(messages))
+	for _, message := range messages {
+		h.logger.Info("message received: \n" + message)
+		var p models.Person
+		if err := json.Unmarshal([]byte(message), &p); err != nil {
+			h.sendFailed("invalid format: " + err.Error())
+			continue
+		}
+		if len(p.Name) == 0 || len(p.Surname) == 0 {
+			h.sendFailed("can't create a person: " + repositoryErrors.MissingRequiredFields.Error())
+			continue
+		}
+
+		persons = append(persons, models.Person{
+			Name:        p.Name,
+			Surname:     p.Surname,
+			Patronymic:  p.Patronymic,
+			CountryHint: p.CountryHint,
+			ExternalId:  p.ExternalId,
+			Status:      models.PersonStatusPendingEnrichment,
+		})
+	}
+
+	h.createMany(persons)
+}
+
+func (h *Handler) sendFailed(message string) {
+	if err := h.service.Kafka.SendMessages("FIO_FAILED", message); err != nil {
+		h.logger.Error("can't send error message to the topic")
+	}
+}
+
 func (h *Handler) handleMessage(message string) {
+	h.processMessage(message, maxMessageAttempts)
+}
+
+// maxMessageAttempts bounds how many times a message is retried after its
+// provider quota resets before it is reported to FIO_FAILED.
+const maxMessageAttempts = 3
+
+// processMessage creates the person of message, making up to attempts
+// attempts when a provider quota is exhausted.
+func (h *Handler) processMessage(message string, attempts int) {
 	h.logger.Info("message received: \n" + message)
 	var p models.Person
 
@@ -212,14 +759,42 @@ func (h *Handler) handleMessage(message string) {
 		return
 	}
 
-	if err := h.service.Person.CreateWithEnrichment(context.Background(), &models.Person{
-		Name:       p.Name,
-		Surname:    p.Surname,
-		Patronymic: p.Patronymic,
-	}); err != nil {
-		if errIn := h.service.Kafka.SendMessages("FIO_FAILED", "can't create a person: "+err.Error()); errIn != nil {
-			h.logger.Error("can't send error message to the topic")
+	for attempt := 1; ; attempt++ {
+		person := &models.Person{
+			Name:        p.Name,
+			Surname:     p.Surname,
+			Patronymic:  p.Patronymic,
+			CountryHint: p.CountryHint,
+			ExternalId:  p.ExternalId,
 		}
-		return
+		_, err := h.service.Person.UpsertWithEnrichment(consumerContext(), person)
+		if err == nil {
+			return
+		}
+		if attempt >= attempts || !h.postpone(err) {
+			h.sendFailed("can't create a person: " + err.Error())
+			return
+		}
+	}
+}
+
+// postpone pauses the consumer until the provider quota behind err resets, so
+// the message is retried instead of being reported to FIO_FAILED. It returns
+// false for errors that are not caused by an exhausted quota and when the
+// consumer is stopped while waiting.
+func (h *Handler) postpone(err error) bool {
+	var quotaErr *enrichmentErrors.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	h.logger.Warn("enrichment quota of " + quotaErr.Provider + " exhausted, postponing messages for " + quotaErr.RetryAfter.String())
+
+	timer := time.NewTimer(quotaErr.RetryAfter)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-h.service.Kafka.Done():
+		return false
 	}
 }
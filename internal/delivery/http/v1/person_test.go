@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"fio_finder/internal/models"
+	"fio_finder/internal/service"
+	mock_service "fio_finder/internal/service/mocks"
+	"fio_finder/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"testing"
+)
+
+func TestHandler_handleMessages(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	personMock := mock_service.NewMockPersonService(ctrl)
+	kafkaMock := mock_service.NewMockKafkaService(ctrl)
+	h := NewHandler(&service.Services{Person: personMock, Kafka: kafkaMock}, logger.New("/dev/null", ""), false)
+
+	known := models.Person{Name: "Vasya", Surname: "Pupkin"}
+	fresh := models.Person{Name: "Petya", Surname: "Ivanov"}
+
+	personMock.EXPECT().IsKnown(gomock.Any(), &known).Return(true, nil)
+	personMock.EXPECT().IsKnown(gomock.Any(), &fresh).Return(false, nil)
+	// The known person is upserted as it is and never enriched.
+	personMock.EXPECT().Upsert(gomock.Any(), &known).Return(false, nil)
+	personMock.EXPECT().BatchEnrich(gomock.Any(), []models.Person{fresh}).Return(nil)
+	personMock.EXPECT().CreateMany(gomock.Any(), []models.Person{fresh}).Return([]uint64{2}, nil)
+
+	h.handleMessages([]string{
+		`{"name": "Vasya", "surname": "Pupkin"}`,
+		`{"name": "Petya", "surname": "Ivanov"}`,
+	})
+}
+
+func TestHandler_handleMessagesAllKnown(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	personMock := mock_service.NewMockPersonService(ctrl)
+	kafkaMock := mock_service.NewMockKafkaService(ctrl)
+	h := NewHandler(&service.Services{Person: personMock, Kafka: kafkaMock}, logger.New("/dev/null", ""), false)
+
+	personMock.EXPECT().IsKnown(gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+	personMock.EXPECT().Upsert(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+
+	h.handleMessages([]string{
+		`{"name": "Vasya", "surname": "Pupkin"}`,
+		`{"name": "Vasya", "surname": "Pupkin"}`,
+	})
+}
@@ -0,0 +1,272 @@
+package api_enricher
+
+import (
+	"context"
+	"encoding/json"
+	"fio_finder/internal/config"
+	"fio_finder/internal/enrichment"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type ageResponse struct {
+	Count int64   `json:"count"`
+	Name  string  `json:"name"`
+	Age   *uint64 `json:"age"`
+}
+
+type genderResponse struct {
+	Count       int64   `json:"count"`
+	Name        string  `json:"name"`
+	Gender      string  `json:"gender"`
+	Probability float64 `json:"probability"`
+}
+
+type country struct {
+	Country_id  string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+type nationalityResponse struct {
+	Count   int64     `json:"count"`
+	Name    string    `json:"name"`
+	Country []country `json:"country"`
+}
+
+const (
+	AgifyProvider       = "agify"
+	GenderizeProvider   = "genderize"
+	NationalizeProvider = "nationalize"
+
+	maxBatchSize = 10
+)
+
+type ApiEnricher struct {
+	client      *http.Client
+	agify       config.EnrichmentProviderConfig
+	genderize   config.EnrichmentProviderConfig
+	nationalize config.EnrichmentProviderConfig
+	quotas      map[string]*quota
+}
+
+func NewApiEnricher(cfg config.EnrichmentConfig) enrichment.Enricher {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ApiEnricher{
+		client:      client,
+		agify:       cfg.Agify,
+		genderize:   cfg.Genderize,
+		nationalize: cfg.Nationalize,
+		quotas: map[string]*quota{
+			AgifyProvider:       newQuota(cfg.QuotaReserve, cfg.QuotaMaxWait),
+			GenderizeProvider:   newQuota(cfg.QuotaReserve, cfg.QuotaMaxWait),
+			NationalizeProvider: newQuota(cfg.QuotaReserve, cfg.QuotaMaxWait),
+		},
+	}
+}
+
+func (e *ApiEnricher) GetAge(ctx context.Context, name string, countryId string) (*models.AgeEnrichment, error) {
+	resp := new(ageResponse)
+	if err := e.getJson(ctx, AgifyProvider, e.agify, []string{name}, countryId, false, resp); err != nil {
+		return nil, &enrichmentErrors.ProviderError{Provider: AgifyProvider, Err: err}
+	}
+	res := resp.toModel()
+	if res == nil {
+		return nil, &enrichmentErrors.ProviderError{Provider: AgifyProvider, Err: enrichmentErrors.EmptyResult}
+	}
+	return res, nil
+}
+
+func (e *ApiEnricher) GetGender(ctx context.Context, name string, countryId string) (*models.GenderEnrichment, error) {
+	resp := new(genderResponse)
+	if err := e.getJson(ctx, GenderizeProvider, e.genderize, []string{name}, countryId, false, resp); err != nil {
+		return nil, &enrichmentErrors.ProviderError{Provider: GenderizeProvider, Err: err}
+	}
+	res := resp.toModel()
+	if res == nil {
+		return nil, &enrichmentErrors.ProviderError{Provider: GenderizeProvider, Err: enrichmentErrors.EmptyResult}
+	}
+	return res, nil
+}
+
+func (e *ApiEnricher) GetNationality(ctx context.Context, name string) (*models.NationalityEnrichment, error) {
+	resp := new(nationalityResponse)
+	if err := e.getJson(ctx, NationalizeProvider, e.nationalize, []string{name}, "", false, resp); err != nil {
+		return nil, &enrichmentErrors.ProviderError{Provider: NationalizeProvider, Err: err}
+	}
+	res := resp.toModel()
+	if res == nil {
+		return nil, &enrichmentErrors.ProviderError{Provider: NationalizeProvider, Err: enrichmentErrors.EmptyResult}
+	}
+	return res, nil
+}
+
+func (e *ApiEnricher) GetAges(ctx context.Context, names []string, countryId string) (map[string]*models.AgeEnrichment, error) {
+	res := make(map[string]*models.AgeEnrichment, len(names))
+	for _, chunk := range chunkNames(names) {
+		var resp []ageResponse
+		if err := e.getJson(ctx, AgifyProvider, e.agify, chunk, countryId, true, &resp); err != nil {
+			return nil, &enrichmentErrors.ProviderError{Provider: AgifyProvider, Err: err}
+		}
+		for i := 0; i < len(resp) && i < len(chunk); i++ {
+			if age := resp[i].toModel(); age != nil {
+				res[chunk[i]] = age
+			}
+		}
+	}
+	return res, nil
+}
+
+func (e *ApiEnricher) GetGenders(ctx context.Context, names []string, countryId string) (map[string]*models.GenderEnrichment, error) {
+	res := make(map[string]*models.GenderEnrichment, len(names))
+	for _, chunk := range chunkNames(names) {
+		var resp []genderResponse
+		if err := e.getJson(ctx, GenderizeProvider, e.genderize, chunk, countryId, true, &resp); err != nil {
+			return nil, &enrichmentErrors.ProviderError{Provider: GenderizeProvider, Err: err}
+		}
+		for i := 0; i < len(resp) && i < len(chunk); i++ {
+			if gender := resp[i].toModel(); gender != nil {
+				res[chunk[i]] = gender
+			}
+		}
+	}
+	return res, nil
+}
+
+func (e *ApiEnricher) GetNationalities(ctx context.Context, names []string) (map[string]*models.NationalityEnrichment, error) {
+	res := make(map[string]*models.NationalityEnrichment, len(names))
+	for _, chunk := range chunkNames(names) {
+		var resp []nationalityResponse
+		if err := e.getJson(ctx, NationalizeProvider, e.nationalize, chunk, "", true, &resp); err != nil {
+			return nil, &enrichmentErrors.ProviderError{Provider: NationalizeProvider, Err: err}
+		}
+		for i := 0; i < len(resp) && i < len(chunk); i++ {
+			if nationality := resp[i].toModel(); nationality != nil {
+				res[chunk[i]] = nationality
+			}
+		}
+	}
+	return res, nil
+}
+
+func (r *ageResponse) toModel() *models.AgeEnrichment {
+	if r.Age == nil {
+		return nil
+	}
+	return &models.AgeEnrichment{
+		Age:      *r.Age,
+		Count:    r.Count,
+		Provider: AgifyProvider,
+	}
+}
+
+func (r *genderResponse) toModel() *models.GenderEnrichment {
+	if r.Gender == "" {
+		return nil
+	}
+	return &models.GenderEnrichment{
+		Gender:      models.PersonGender(strings.ToUpper(r.Gender[:1]) + r.Gender[1:]),
+		Probability: r.Probability,
+		Count:       r.Count,
+		Provider:    GenderizeProvider,
+	}
+}
+
+func (r *nationalityResponse) toModel() *models.NationalityEnrichment {
+	if len(r.Country) == 0 {
+		return nil
+	}
+	countries := make([]models.CountryProbability, 0, len(r.Country))
+	for _, c := range r.Country {
+		countries = append(countries, models.CountryProbability{
+			CountryId:   c.Country_id,
+			Probability: c.Probability,
+		})
+	}
+	return &models.NationalityEnrichment{
+		Countries: countries,
+		Count:     r.Count,
+		Provider:  NationalizeProvider,
+	}
+}
+
+// chunkNames removes duplicates and splits names into groups that fit into
+// a single multi-name request.
+func chunkNames(names []string) [][]string {
+	seen := make(map[string]struct{}, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		unique = append(unique, name)
+	}
+
+	chunks := make([][]string, 0, len(unique)/maxBatchSize+1)
+	for len(unique) > maxBatchSize {
+		chunks = append(chunks, unique[:maxBatchSize])
+		unique = unique[maxBatchSize:]
+	}
+	if len(unique) > 0 {
+		chunks = append(chunks, unique)
+	}
+	return chunks
+}
+
+// getJson sends a single-name request, answered with an object, unless batch
+// is set. A batch is always sent as a multi-name (name[]) request, even for
+// one name, so the provider answers with an array in the order of the names. Age and gender providers accept a
+// country_id hint; the nationality provider is always queried without one.
+// Requests are paced by the quota the provider reports in its rate-limit
+// headers.
+func (e *ApiEnricher) getJson(ctx context.Context, name string, provider config.EnrichmentProviderConfig, names []string, countryId string, batch bool, target interface{}) error {
+	q := e.quotas[name]
+	if err := q.acquire(ctx, name, len(names)); err != nil {
+		return err
+	}
+
+	if provider.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, provider.Timeout)
+		defer cancel()
+	}
+
+	params := url.Values{}
+	if batch {
+		params["name[]"] = names
+	} else {
+		params.Set("name", names[0])
+	}
+	if countryId != "" {
+		params.Set("country_id", countryId)
+	}
+	if provider.ApiKey != "" {
+		params.Set("apikey", provider.ApiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	r, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	q.update(r)
+	if r.StatusCode == http.StatusTooManyRequests {
+		return &enrichmentErrors.QuotaError{Provider: name, RetryAfter: q.retryAfter()}
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %d", enrichmentErrors.UnexpectedStatus, r.StatusCode)
+	}
+	return json.NewDecoder(r.Body).Decode(target)
+}
@@ -0,0 +1,240 @@
+package api_enricher
+
+import (
+	"context"
+	"encoding/json"
+	"fio_finder/internal/config"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func createStubEnricher(t *testing.T, handler http.HandlerFunc) *ApiEnricher {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	provider := config.EnrichmentProviderConfig{BaseURL: srv.URL + "/", ApiKey: "secret"}
+	return NewApiEnricher(config.EnrichmentConfig{
+		Agify:       provider,
+		Genderize:   provider,
+		Nationalize: provider,
+		HTTPClient:  srv.Client(),
+	}).(*ApiEnricher)
+}
+
+func TestApiEnricher_GetAge(t *testing.T) {
+	t.Parallel()
+
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Vasya", r.URL.Query().Get("name"))
+		require.Equal(t, "secret", r.URL.Query().Get("apikey"))
+		require.Equal(t, "KZ", r.URL.Query().Get("country_id"))
+		_, _ = w.Write([]byte(`{"count": 10, "name": "Vasya", "age": 42}`))
+	})
+
+	age, err := enricher.GetAge(context.Background(), "Vasya", "KZ")
+	require.NoError(t, err)
+	require.Equal(t, &models.AgeEnrichment{Age: 42, Count: 10, Provider: AgifyProvider}, age)
+}
+
+func TestApiEnricher_GetAgeUnknown(t *testing.T) {
+	t.Parallel()
+
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"count": 0, "name": "Zyx", "age": null}`))
+	})
+
+	_, err := enricher.GetAge(context.Background(), "Zyx", "")
+	require.ErrorIs(t, err, enrichmentErrors.EmptyResult)
+}
+
+func TestApiEnricher_GetGender(t *testing.T) {
+	t.Parallel()
+
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"count": 10, "name": "Vasya", "gender": "male", "probability": 0.99}`))
+	})
+
+	gender, err := enricher.GetGender(context.Background(), "Vasya", "")
+	require.NoError(t, err)
+	require.Equal(t, &models.GenderEnrichment{Gender: models.MaleUserGender, Probability: 0.99, Count: 10, Provider: GenderizeProvider}, gender)
+}
+
+func TestApiEnricher_GetNationality(t *testing.T) {
+	t.Parallel()
+
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"count": 10, "name": "Vasya", "country": []}`))
+	})
+
+	_, err := enricher.GetNationality(context.Background(), "Vasya")
+	require.ErrorIs(t, err, enrichmentErrors.EmptyResult)
+}
+
+func TestApiEnricher_GetGenders(t *testing.T) {
+	t.Parallel()
+
+	names := func(count int) []string {
+		res := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			res = append(res, "Name"+strconv.Itoa(i))
+		}
+		return res
+	}
+
+	tests := []struct {
+		TestName string
+		Names    []string
+		Requests int32
+		Genders  int
+	}{
+		{TestName: "one name", Names: []string{"Ivan"}, Requests: 1, Genders: 1},
+		{TestName: "duplicate names", Names: []string{"Ivan", "Ivan"}, Requests: 1, Genders: 1},
+		{TestName: "last chunk of one name", Names: names(11), Requests: 2, Genders: 11},
+		{TestName: "unknown name", Names: append([]string{"Unknown"}, names(11)...), Requests: 2, Genders: 11},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				// Like the provider, a single-name request is answered with
+				// an object and only a multi-name one with an array.
+				if name := r.URL.Query().Get("name"); name != "" {
+					require.NoError(t, json.NewEncoder(w).Encode(genderResponse{Name: name, Gender: "male", Probability: 0.9}))
+					return
+				}
+				names := r.URL.Query()["name[]"]
+				require.LessOrEqual(t, len(names), maxBatchSize)
+
+				resp := make([]genderResponse, 0, len(names))
+				for _, name := range names {
+					gender := "male"
+					if name == "Unknown" {
+						gender = ""
+					}
+					resp = append(resp, genderResponse{Name: name, Gender: gender, Probability: 0.9})
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(resp))
+			})
+
+			genders, err := enricher.GetGenders(context.Background(), tt.Names, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.Requests, atomic.LoadInt32(&requests))
+			require.Len(t, genders, tt.Genders)
+			require.NotContains(t, genders, "Unknown")
+			require.Equal(t, models.MaleUserGender, genders[tt.Names[len(tt.Names)-1]].Gender)
+		})
+	}
+}
+
+func TestApiEnricher_ProviderTimeout(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	enricher := NewApiEnricher(config.EnrichmentConfig{
+		Genderize:  config.EnrichmentProviderConfig{BaseURL: srv.URL + "/", Timeout: 10 * time.Millisecond},
+		HTTPClient: srv.Client(),
+	})
+
+	_, err := enricher.GetGender(context.Background(), "Vasya", "")
+
+	var providerErr *enrichmentErrors.ProviderError
+	require.ErrorAs(t, err, &providerErr)
+	require.Equal(t, GenderizeProvider, providerErr.Provider)
+	require.True(t, providerErr.Timeout())
+}
+
+func TestApiEnricher_UnexpectedStatus(t *testing.T) {
+	t.Parallel()
+
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := enricher.GetAge(context.Background(), "Vasya", "")
+	require.ErrorIs(t, err, enrichmentErrors.UnexpectedStatus)
+}
+
+func TestApiEnricher_QuotaExhausted(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set(rateLimitRemainingHeader, "0")
+		w.Header().Set(rateLimitResetHeader, "3600")
+		_, _ = w.Write([]byte(`{"count": 10, "name": "Vasya", "age": 42}`))
+	})
+
+	_, err := enricher.GetAge(context.Background(), "Vasya", "")
+	require.NoError(t, err)
+
+	_, err = enricher.GetAge(context.Background(), "Vasya", "")
+	require.ErrorIs(t, err, enrichmentErrors.QuotaExceeded)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	var quotaErr *enrichmentErrors.QuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	require.Equal(t, AgifyProvider, quotaErr.Provider)
+	require.InDelta(t, time.Hour, quotaErr.RetryAfter, float64(time.Minute))
+
+	_, err = enricher.GetGender(context.Background(), "Vasya", "")
+	require.NotErrorIs(t, err, enrichmentErrors.QuotaExceeded)
+}
+
+func TestApiEnricher_TooManyRequests(t *testing.T) {
+	t.Parallel()
+
+	enricher := createStubEnricher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(rateLimitResetHeader, "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, err := enricher.GetNationality(context.Background(), "Vasya")
+
+	var quotaErr *enrichmentErrors.QuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	require.Equal(t, NationalizeProvider, quotaErr.Provider)
+	require.InDelta(t, time.Minute, quotaErr.RetryAfter, float64(time.Second))
+}
+
+func TestApiEnricher_QuotaPacing(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(rateLimitRemainingHeader, "1")
+		w.Header().Set(rateLimitResetHeader, "1")
+		_, _ = w.Write([]byte(`{"count": 10, "name": "Vasya", "age": 42}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	enricher := NewApiEnricher(config.EnrichmentConfig{
+		Agify:        config.EnrichmentProviderConfig{BaseURL: srv.URL + "/"},
+		HTTPClient:   srv.Client(),
+		QuotaReserve: 10,
+		QuotaMaxWait: time.Second,
+	})
+
+	_, err := enricher.GetAge(context.Background(), "Vasya", "")
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = enricher.GetAge(context.Background(), "Vasya", "")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
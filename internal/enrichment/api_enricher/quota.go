@@ -0,0 +1,115 @@
+package api_enricher
+
+import (
+	"context"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitRemainingHeader = "X-Rate-Limit-Remaining"
+	rateLimitResetHeader     = "X-Rate-Limit-Reset"
+
+	// defaultQuotaRetryAfter is used when a provider rejects a request for
+	// exceeding its quota without telling when the quota resets.
+	defaultQuotaRetryAfter = time.Minute
+)
+
+// quota tracks the request allowance a provider reported in its last
+// response. Every name in a request counts against the allowance.
+type quota struct {
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	resetAt   time.Time
+
+	reserve int
+	maxWait time.Duration
+}
+
+func newQuota(reserve int, maxWait time.Duration) *quota {
+	return &quota{reserve: reserve, maxWait: maxWait}
+}
+
+// acquire reserves cost requests. Once the remaining allowance drops to the
+// reserve, requests are spread evenly over the time left until the reset.
+// A QuotaError is returned when the allowance cannot cover the request or
+// the required pause is longer than maxWait.
+func (q *quota) acquire(ctx context.Context, provider string, cost int) error {
+	q.mu.Lock()
+	now := time.Now()
+	if !q.known || !now.Before(q.resetAt) {
+		q.known = false
+		q.mu.Unlock()
+		return nil
+	}
+
+	untilReset := q.resetAt.Sub(now)
+	if q.remaining < cost {
+		q.mu.Unlock()
+		return &enrichmentErrors.QuotaError{Provider: provider, RetryAfter: untilReset}
+	}
+
+	var delay time.Duration
+	if q.remaining <= q.reserve {
+		delay = untilReset / time.Duration(q.remaining/cost+1)
+	}
+	if delay > q.maxWait {
+		q.mu.Unlock()
+		return &enrichmentErrors.QuotaError{Provider: provider, RetryAfter: delay}
+	}
+	q.remaining -= cost
+	q.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// update refreshes the allowance from the rate-limit headers. A 429 response
+// exhausts the allowance until the reported reset.
+func (q *quota) update(r *http.Response) {
+	remaining, remainingErr := strconv.Atoi(r.Header.Get(rateLimitRemainingHeader))
+	reset, resetErr := strconv.Atoi(r.Header.Get(rateLimitResetHeader))
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if r.StatusCode == http.StatusTooManyRequests {
+		q.known = true
+		q.remaining = 0
+		if resetErr == nil {
+			q.resetAt = time.Now().Add(time.Duration(reset) * time.Second)
+		} else {
+			q.resetAt = time.Now().Add(defaultQuotaRetryAfter)
+		}
+		return
+	}
+	if remainingErr != nil || resetErr != nil {
+		return
+	}
+	q.known = true
+	q.remaining = remaining
+	q.resetAt = time.Now().Add(time.Duration(reset) * time.Second)
+}
+
+// retryAfter tells how long to wait before the allowance is restored.
+func (q *quota) retryAfter() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if d := time.Until(q.resetAt); d > 0 {
+		return d
+	}
+	return defaultQuotaRetryAfter
+}
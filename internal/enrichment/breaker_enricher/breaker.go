@@ -0,0 +1,81 @@
+package breaker_enricher
+
+import (
+	"fio_finder/internal/models"
+	"sync"
+	"time"
+)
+
+// breaker is a consecutive-failure circuit breaker. While half-open it lets
+// a single probe request through and decides the next state by its outcome.
+type breaker struct {
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+
+	threshold   int
+	openTimeout time.Duration
+}
+
+func newBreaker(threshold int, openTimeout time.Duration) *breaker {
+	return &breaker{
+		state:       models.CircuitClosed,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case models.CircuitOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = models.CircuitHalfOpen
+		b.probing = true
+		return true
+	case models.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.state = models.CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == models.CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = models.CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *breaker) health(provider string) models.ProviderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return models.ProviderHealth{
+		Provider: provider,
+		State:    b.state,
+		Failures: b.failures,
+		OpenedAt: b.openedAt,
+	}
+}
@@ -0,0 +1,122 @@
+package breaker_enricher
+
+import (
+	"context"
+	"errors"
+	"fio_finder/internal/config"
+	"fio_finder/internal/enrichment"
+	"fio_finder/internal/enrichment/api_enricher"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"fmt"
+)
+
+// BreakerEnricher guards every provider of the API backend with its own
+// circuit breaker. While a circuit is open requests to that provider are not
+// sent and the configured degraded policy applies instead.
+type BreakerEnricher struct {
+	enricher  enrichment.Enricher
+	secondary enrichment.Enricher
+	policy    string
+
+	providers []string
+	breakers  map[string]*breaker
+}
+
+// NewBreakerEnricher wraps enricher. secondary is only used with the
+// secondary degraded policy and may be nil otherwise.
+func NewBreakerEnricher(enricher enrichment.Enricher, secondary enrichment.Enricher, cfg config.BreakerConfig) *BreakerEnricher {
+	providers := []string{api_enricher.AgifyProvider, api_enricher.GenderizeProvider, api_enricher.NationalizeProvider}
+	breakers := make(map[string]*breaker, len(providers))
+	for _, provider := range providers {
+		breakers[provider] = newBreaker(cfg.Threshold, cfg.OpenTimeout)
+	}
+	return &BreakerEnricher{
+		enricher:  enricher,
+		secondary: secondary,
+		policy:    cfg.Policy,
+		providers: providers,
+		breakers:  breakers,
+	}
+}
+
+func (e *BreakerEnricher) GetAge(ctx context.Context, name string, countryId string) (*models.AgeEnrichment, error) {
+	return call(e, api_enricher.AgifyProvider, func(enricher enrichment.Enricher) (*models.AgeEnrichment, error) {
+		return enricher.GetAge(ctx, name, countryId)
+	})
+}
+
+func (e *BreakerEnricher) GetGender(ctx context.Context, name string, countryId string) (*models.GenderEnrichment, error) {
+	return call(e, api_enricher.GenderizeProvider, func(enricher enrichment.Enricher) (*models.GenderEnrichment, error) {
+		return enricher.GetGender(ctx, name, countryId)
+	})
+}
+
+func (e *BreakerEnricher) GetNationality(ctx context.Context, name string) (*models.NationalityEnrichment, error) {
+	return call(e, api_enricher.NationalizeProvider, func(enricher enrichment.Enricher) (*models.NationalityEnrichment, error) {
+		return enricher.GetNationality(ctx, name)
+	})
+}
+
+func (e *BreakerEnricher) GetAges(ctx context.Context, names []string, countryId string) (map[string]*models.AgeEnrichment, error) {
+	return call(e, api_enricher.AgifyProvider, func(enricher enrichment.Enricher) (map[string]*models.AgeEnrichment, error) {
+		return enricher.GetAges(ctx, names, countryId)
+	})
+}
+
+func (e *BreakerEnricher) GetGenders(ctx context.Context, names []string, countryId string) (map[string]*models.GenderEnrichment, error) {
+	return call(e, api_enricher.GenderizeProvider, func(enricher enrichment.Enricher) (map[string]*models.GenderEnrichment, error) {
+		return enricher.GetGenders(ctx, names, countryId)
+	})
+}
+
+func (e *BreakerEnricher) GetNationalities(ctx context.Context, names []string) (map[string]*models.NationalityEnrichment, error) {
+	return call(e, api_enricher.NationalizeProvider, func(enricher enrichment.Enricher) (map[string]*models.NationalityEnrichment, error) {
+		return enricher.GetNationalities(ctx, names)
+	})
+}
+
+func (e *BreakerEnricher) Health() []models.ProviderHealth {
+	res := make([]models.ProviderHealth, 0, len(e.providers))
+	for _, provider := range e.providers {
+		res = append(res, e.breakers[provider].health(provider))
+	}
+	return res
+}
+
+func call[T any](e *BreakerEnricher, provider string, fn func(enricher enrichment.Enricher) (T, error)) (T, error) {
+	b := e.breakers[provider]
+	if !b.allow() {
+		return degraded(e, provider, fn)
+	}
+
+	res, err := fn(e.enricher)
+	b.record(isOutage(err))
+	return res, err
+}
+
+func degraded[T any](e *BreakerEnricher, provider string, fn func(enricher enrichment.Enricher) (T, error)) (T, error) {
+	switch {
+	case e.policy == config.DegradedPolicySecondary && e.secondary != nil:
+		return fn(e.secondary)
+	case e.policy == config.DegradedPolicySaveUnenriched:
+		var zero T
+		return zero, &enrichmentErrors.ProviderError{
+			Provider: provider,
+			Err:      fmt.Errorf("%w, %w", enrichmentErrors.CircuitOpen, enrichmentErrors.FieldSkipped),
+		}
+	default:
+		var zero T
+		return zero, &enrichmentErrors.ProviderError{Provider: provider, Err: enrichmentErrors.CircuitOpen}
+	}
+}
+
+// isOutage tells whether err means the provider is unavailable. Unknown
+// names, exhausted quotas and requests cancelled by the caller say nothing
+// about provider health.
+func isOutage(err error) bool {
+	return err != nil &&
+		!errors.Is(err, enrichmentErrors.EmptyResult) &&
+		!errors.Is(err, enrichmentErrors.QuotaExceeded) &&
+		!errors.Is(err, context.Canceled)
+}
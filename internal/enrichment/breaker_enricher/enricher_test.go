@@ -0,0 +1,123 @@
+package breaker_enricher
+
+import (
+	"context"
+	"errors"
+	"fio_finder/internal/config"
+	"fio_finder/internal/enrichment/api_enricher"
+	mock_enrichment "fio_finder/internal/enrichment/mocks"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+func TestBreakerEnricher_Policies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		TestName    string
+		Policy      string
+		Prepare     func(secondary *mock_enrichment.MockEnricher)
+		CheckOutput func(t *testing.T, age *models.AgeEnrichment, err error)
+	}{
+		{
+			TestName: "reject",
+			Policy:   config.DegradedPolicyReject,
+			Prepare:  func(secondary *mock_enrichment.MockEnricher) {},
+			CheckOutput: func(t *testing.T, age *models.AgeEnrichment, err error) {
+				require.ErrorIs(t, err, enrichmentErrors.CircuitOpen)
+				require.NotErrorIs(t, err, enrichmentErrors.FieldSkipped)
+			},
+		},
+		{
+			TestName: "save unenriched",
+			Policy:   config.DegradedPolicySaveUnenriched,
+			Prepare:  func(secondary *mock_enrichment.MockEnricher) {},
+			CheckOutput: func(t *testing.T, age *models.AgeEnrichment, err error) {
+				require.ErrorIs(t, err, enrichmentErrors.CircuitOpen)
+				require.ErrorIs(t, err, enrichmentErrors.FieldSkipped)
+			},
+		},
+		{
+			TestName: "secondary",
+			Policy:   config.DegradedPolicySecondary,
+			Prepare: func(secondary *mock_enrichment.MockEnricher) {
+				secondary.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(&models.AgeEnrichment{Age: 30, Provider: "offline"}, nil)
+			},
+			CheckOutput: func(t *testing.T, age *models.AgeEnrichment, err error) {
+				require.NoError(t, err)
+				require.Equal(t, &models.AgeEnrichment{Age: 30, Provider: "offline"}, age)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			primary := mock_enrichment.NewMockEnricher(ctrl)
+			secondary := mock_enrichment.NewMockEnricher(ctrl)
+			primary.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(nil, errUnavailable).Times(2)
+			tt.Prepare(secondary)
+
+			enricher := NewBreakerEnricher(primary, secondary, config.BreakerConfig{Threshold: 2, OpenTimeout: time.Hour, Policy: tt.Policy})
+			for i := 0; i < 2; i++ {
+				_, err := enricher.GetAge(context.Background(), "Vasya", "")
+				require.ErrorIs(t, err, errUnavailable)
+			}
+
+			age, err := enricher.GetAge(context.Background(), "Vasya", "")
+			tt.CheckOutput(t, age, err)
+		})
+	}
+}
+
+func TestBreakerEnricher_HalfOpen(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := mock_enrichment.NewMockEnricher(ctrl)
+	gomock.InOrder(
+		primary.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(nil, errUnavailable),
+		primary.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil),
+	)
+	// Unknown names do not count as failures.
+	primary.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(nil, enrichmentErrors.EmptyResult).Times(2)
+
+	enricher := NewBreakerEnricher(primary, nil, config.BreakerConfig{Threshold: 1, OpenTimeout: 10 * time.Millisecond})
+
+	_, err := enricher.GetAge(context.Background(), "Vasya", "")
+	require.ErrorIs(t, err, enrichmentErrors.EmptyResult)
+	_, err = enricher.GetAge(context.Background(), "Vasya", "")
+	require.ErrorIs(t, err, enrichmentErrors.EmptyResult)
+
+	_, err = enricher.GetGender(context.Background(), "Vasya", "")
+	require.ErrorIs(t, err, errUnavailable)
+	require.Equal(t, models.CircuitOpen, health(enricher, api_enricher.GenderizeProvider).State)
+
+	time.Sleep(20 * time.Millisecond)
+	_, err = enricher.GetGender(context.Background(), "Vasya", "")
+	require.NoError(t, err)
+	require.Equal(t, models.CircuitClosed, health(enricher, api_enricher.GenderizeProvider).State)
+	require.Equal(t, models.CircuitClosed, health(enricher, api_enricher.AgifyProvider).State)
+}
+
+func health(enricher *BreakerEnricher, provider string) models.ProviderHealth {
+	for _, h := range enricher.Health() {
+		if h.Provider == provider {
+			return h
+		}
+	}
+	return models.ProviderHealth{}
+}
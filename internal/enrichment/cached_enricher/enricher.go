@@ -0,0 +1,145 @@
+package cached_enricher
+
+import (
+	"context"
+	"fio_finder/internal/enrichment"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/cache"
+	"strings"
+	"time"
+)
+
+const (
+	ageKeyPrefix         = "enrichment:age:"
+	genderKeyPrefix      = "enrichment:gender:"
+	nationalityKeyPrefix = "enrichment:nationality:"
+)
+
+type CachedEnricher struct {
+	enricher enrichment.Enricher
+	cache    cache.Cache
+	ttl      time.Duration
+}
+
+func NewCachedEnricher(enricher enrichment.Enricher, cache cache.Cache, ttl time.Duration) enrichment.Enricher {
+	return &CachedEnricher{
+		enricher: enricher,
+		cache:    cache,
+		ttl:      ttl,
+	}
+}
+
+func (e *CachedEnricher) GetAge(ctx context.Context, name string, countryId string) (*models.AgeEnrichment, error) {
+	key := cacheKey(ageKeyPrefix, name, countryId)
+	res := new(models.AgeEnrichment)
+	if e.load(ctx, key, res) {
+		return res, nil
+	}
+
+	res, err := e.enricher.GetAge(ctx, name, countryId)
+	if err != nil {
+		return nil, err
+	}
+	e.store(ctx, key, res)
+	return res, nil
+}
+
+func (e *CachedEnricher) GetGender(ctx context.Context, name string, countryId string) (*models.GenderEnrichment, error) {
+	key := cacheKey(genderKeyPrefix, name, countryId)
+	res := new(models.GenderEnrichment)
+	if e.load(ctx, key, res) {
+		return res, nil
+	}
+
+	res, err := e.enricher.GetGender(ctx, name, countryId)
+	if err != nil {
+		return nil, err
+	}
+	e.store(ctx, key, res)
+	return res, nil
+}
+
+func (e *CachedEnricher) GetNationality(ctx context.Context, name string) (*models.NationalityEnrichment, error) {
+	key := cacheKey(nationalityKeyPrefix, name, "")
+	res := new(models.NationalityEnrichment)
+	if e.load(ctx, key, res) {
+		return res, nil
+	}
+
+	res, err := e.enricher.GetNationality(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	e.store(ctx, key, res)
+	return res, nil
+}
+
+func (e *CachedEnricher) GetAges(ctx context.Context, names []string, countryId string) (map[string]*models.AgeEnrichment, error) {
+	return getBatch(ctx, e, ageKeyPrefix, names, countryId,
+		func(ctx context.Context, names []string) (map[string]*models.AgeEnrichment, error) {
+			return e.enricher.GetAges(ctx, names, countryId)
+		})
+}
+
+func (e *CachedEnricher) GetGenders(ctx context.Context, names []string, countryId string) (map[string]*models.GenderEnrichment, error) {
+	return getBatch(ctx, e, genderKeyPrefix, names, countryId,
+		func(ctx context.Context, names []string) (map[string]*models.GenderEnrichment, error) {
+			return e.enricher.GetGenders(ctx, names, countryId)
+		})
+}
+
+func (e *CachedEnricher) GetNationalities(ctx context.Context, names []string) (map[string]*models.NationalityEnrichment, error) {
+	return getBatch(ctx, e, nationalityKeyPrefix, names, "", e.enricher.GetNationalities)
+}
+
+// getBatch serves cached names directly and fetches only the misses from the
+// wrapped enricher in one batch call.
+func getBatch[T any](ctx context.Context, e *CachedEnricher, prefix string, names []string, countryId string,
+	fetch func(ctx context.Context, names []string) (map[string]*T, error)) (map[string]*T, error) {
+	res := make(map[string]*T, len(names))
+	misses := make([]string, 0, len(names))
+	for _, name := range names {
+		cached := new(T)
+		if e.load(ctx, cacheKey(prefix, name, countryId), cached) {
+			res[name] = cached
+			continue
+		}
+		misses = append(misses, name)
+	}
+	if len(misses) == 0 {
+		return res, nil
+	}
+
+	fetched, err := fetch(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range fetched {
+		e.store(ctx, cacheKey(prefix, name, countryId), value)
+		res[name] = value
+	}
+	return res, nil
+}
+
+func (e *CachedEnricher) load(ctx context.Context, key string, target interface{}) bool {
+	value, err := e.cache.Get(ctx, key)
+	if err != nil || value == nil {
+		return false
+	}
+	return cache.Decode(value, target) == nil
+}
+
+func (e *CachedEnricher) store(ctx context.Context, key string, value interface{}) {
+	// A failed write only costs another provider call next time.
+	_ = e.cache.Set(ctx, key, value, e.ttl)
+}
+
+// cacheKey keeps results for different country hints apart, since the
+// providers return different statistics for them.
+func cacheKey(prefix string, name string, countryId string) string {
+	key := prefix + strings.ToLower(strings.TrimSpace(name))
+	if countryId != "" {
+		key += ":" + strings.ToUpper(countryId)
+	}
+	return key
+}
@@ -0,0 +1,70 @@
+package cached_enricher
+
+import (
+	"context"
+	"encoding/json"
+	mock_enrichment "fio_finder/internal/enrichment/mocks"
+	"fio_finder/internal/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jsonCache mimics the redis cache, which hands values back as generic JSON.
+type jsonCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (c *jsonCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = data
+	return nil
+}
+
+func (c *jsonCache) Get(_ context.Context, key string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var value interface{}
+	if err := json.Unmarshal(c.data[key], &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *jsonCache) Delete(_ context.Context, key ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range key {
+		delete(c.data, k)
+	}
+	return nil
+}
+
+func TestCachedEnricher_GetGender(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inner := mock_enrichment.NewMockEnricher(ctrl)
+	inner.EXPECT().GetGender(gomock.Any(), "Ivan", "RU").
+		Return(&models.GenderEnrichment{Gender: models.MaleUserGender, Probability: 0.99, Count: 10}, nil).
+		Times(1)
+
+	enricher := NewCachedEnricher(inner, &jsonCache{data: map[string][]byte{}}, time.Hour)
+
+	first, err := enricher.GetGender(context.Background(), "Ivan", "RU")
+	require.NoError(t, err)
+
+	second, err := enricher.GetGender(context.Background(), " IVAN ", "ru")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+}
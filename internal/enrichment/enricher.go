@@ -0,0 +1,26 @@
+package enrichment
+
+import (
+	"context"
+	"fio_finder/internal/models"
+)
+
+//go:generate mockgen -source=enricher.go -destination=mocks/enricher.go
+type Enricher interface {
+	// countryId is an optional ISO 3166-1 alpha-2 hint that narrows age and
+	// gender statistics down to one country. Empty means worldwide.
+	GetAge(ctx context.Context, name string, countryId string) (*models.AgeEnrichment, error)
+	GetGender(ctx context.Context, name string, countryId string) (*models.GenderEnrichment, error)
+	GetNationality(ctx context.Context, name string) (*models.NationalityEnrichment, error)
+
+	// Batch lookups return results keyed by the requested name. Names the
+	// provider knows nothing about are left out of the map.
+	GetAges(ctx context.Context, names []string, countryId string) (map[string]*models.AgeEnrichment, error)
+	GetGenders(ctx context.Context, names []string, countryId string) (map[string]*models.GenderEnrichment, error)
+	GetNationalities(ctx context.Context, names []string) (map[string]*models.NationalityEnrichment, error)
+}
+
+// HealthReporter is implemented by enrichers that track provider availability.
+type HealthReporter interface {
+	Health() []models.ProviderHealth
+}
@@ -0,0 +1,111 @@
+package gender_rules
+
+import (
+	"fio_finder/internal/models"
+	"strings"
+)
+
+// Provider marks genders inferred from name endings.
+const Provider = "rules"
+
+const (
+	patronymicConfidence  = 0.99
+	surnameConfidence     = 0.9
+	weakSurnameConfidence = 0.75
+)
+
+type rule struct {
+	suffix     string
+	gender     models.PersonGender
+	confidence float64
+}
+
+// Patronymic endings of Russian (-ovich/-ovna) and Turkic (-uly/-kyzy,
+// -oglu/-gyzy) patronymics, in Latin and Cyrillic spelling. Turkic
+// patronymics are often written as a separate word, which the suffix check
+// covers as well.
+var patronymicRules = []rule{
+	{"ovna", models.FemaleUserGender, patronymicConfidence},
+	{"evna", models.FemaleUserGender, patronymicConfidence},
+	{"ichna", models.FemaleUserGender, patronymicConfidence},
+	{"kyzy", models.FemaleUserGender, patronymicConfidence},
+	{"qyzy", models.FemaleUserGender, patronymicConfidence},
+	{"gyzy", models.FemaleUserGender, patronymicConfidence},
+	{"овна", models.FemaleUserGender, patronymicConfidence},
+	{"евна", models.FemaleUserGender, patronymicConfidence},
+	{"ична", models.FemaleUserGender, patronymicConfidence},
+	{"кызы", models.FemaleUserGender, patronymicConfidence},
+	{"қызы", models.FemaleUserGender, patronymicConfidence},
+	{"гызы", models.FemaleUserGender, patronymicConfidence},
+
+	{"ovich", models.MaleUserGender, patronymicConfidence},
+	{"evich", models.MaleUserGender, patronymicConfidence},
+	{"ich", models.MaleUserGender, patronymicConfidence},
+	{"uly", models.MaleUserGender, patronymicConfidence},
+	{"uulu", models.MaleUserGender, patronymicConfidence},
+	{"oglu", models.MaleUserGender, patronymicConfidence},
+	{"ович", models.MaleUserGender, patronymicConfidence},
+	{"евич", models.MaleUserGender, patronymicConfidence},
+	{"ич", models.MaleUserGender, patronymicConfidence},
+	{"улы", models.MaleUserGender, patronymicConfidence},
+	{"ұлы", models.MaleUserGender, patronymicConfidence},
+	{"уулу", models.MaleUserGender, patronymicConfidence},
+	{"оглы", models.MaleUserGender, patronymicConfidence},
+}
+
+// Surname endings of Russian and russified Kazakh surnames. The -in/-ina
+// pair also ends many unrelated surnames, so it is trusted less.
+var surnameRules = []rule{
+	{"ova", models.FemaleUserGender, surnameConfidence},
+	{"eva", models.FemaleUserGender, surnameConfidence},
+	{"skaya", models.FemaleUserGender, surnameConfidence},
+	{"ina", models.FemaleUserGender, weakSurnameConfidence},
+	{"yna", models.FemaleUserGender, weakSurnameConfidence},
+	{"ова", models.FemaleUserGender, surnameConfidence},
+	{"ева", models.FemaleUserGender, surnameConfidence},
+	{"ская", models.FemaleUserGender, surnameConfidence},
+	{"цкая", models.FemaleUserGender, surnameConfidence},
+	{"ина", models.FemaleUserGender, weakSurnameConfidence},
+	{"ына", models.FemaleUserGender, weakSurnameConfidence},
+
+	{"ov", models.MaleUserGender, surnameConfidence},
+	{"ev", models.MaleUserGender, surnameConfidence},
+	{"sky", models.MaleUserGender, surnameConfidence},
+	{"skiy", models.MaleUserGender, surnameConfidence},
+	{"skii", models.MaleUserGender, surnameConfidence},
+	{"in", models.MaleUserGender, weakSurnameConfidence},
+	{"yn", models.MaleUserGender, weakSurnameConfidence},
+	{"ов", models.MaleUserGender, surnameConfidence},
+	{"ев", models.MaleUserGender, surnameConfidence},
+	{"ский", models.MaleUserGender, surnameConfidence},
+	{"цкий", models.MaleUserGender, surnameConfidence},
+	{"ин", models.MaleUserGender, weakSurnameConfidence},
+	{"ын", models.MaleUserGender, weakSurnameConfidence},
+}
+
+// Infer guesses gender from the patronymic and, failing that, from the
+// surname. It returns nil when neither has a known ending. The confidence of
+// the matched rule is reported as the probability.
+func Infer(patronymic string, surname string) *models.GenderEnrichment {
+	if res := match(patronymicRules, patronymic); res != nil {
+		return res
+	}
+	return match(surnameRules, surname)
+}
+
+func match(rules []rule, value string) *models.GenderEnrichment {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return nil
+	}
+	for _, r := range rules {
+		if strings.HasSuffix(value, r.suffix) {
+			return &models.GenderEnrichment{
+				Gender:      r.gender,
+				Probability: r.confidence,
+				Provider:    Provider,
+			}
+		}
+	}
+	return nil
+}
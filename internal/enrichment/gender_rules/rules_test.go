@@ -0,0 +1,58 @@
+package gender_rules
+
+import (
+	"fio_finder/internal/models"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInfer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		TestName   string
+		Patronymic string
+		Surname    string
+		Expected   *models.GenderEnrichment
+	}{
+		{
+			TestName:   "russian patronymic",
+			Patronymic: "Ivanovich",
+			Surname:    "Ivanova",
+			Expected:   &models.GenderEnrichment{Gender: models.MaleUserGender, Probability: patronymicConfidence, Provider: Provider},
+		},
+		{
+			TestName:   "cyrillic patronymic",
+			Patronymic: "Петровна",
+			Expected:   &models.GenderEnrichment{Gender: models.FemaleUserGender, Probability: patronymicConfidence, Provider: Provider},
+		},
+		{
+			TestName:   "kazakh patronymic",
+			Patronymic: "Nurlan kyzy",
+			Expected:   &models.GenderEnrichment{Gender: models.FemaleUserGender, Probability: patronymicConfidence, Provider: Provider},
+		},
+		{
+			TestName:   "surname fallback",
+			Patronymic: "Smith",
+			Surname:    "Abenova",
+			Expected:   &models.GenderEnrichment{Gender: models.FemaleUserGender, Probability: surnameConfidence, Provider: Provider},
+		},
+		{
+			TestName: "weak surname ending",
+			Surname:  "Pupkin",
+			Expected: &models.GenderEnrichment{Gender: models.MaleUserGender, Probability: weakSurnameConfidence, Provider: Provider},
+		},
+		{
+			TestName: "unknown endings",
+			Surname:  "Smith",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.Expected, Infer(tt.Patronymic, tt.Surname))
+		})
+	}
+}
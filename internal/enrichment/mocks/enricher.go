@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: enricher.go
+
+// Package mock_enrichment is a generated GoMock package.
+package mock_enrichment
+
+import (
+	context "context"
+	models "fio_finder/internal/models"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEnricher is a mock of Enricher interface.
+type MockEnricher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnricherMockRecorder
+}
+
+// MockEnricherMockRecorder is the mock recorder for MockEnricher.
+type MockEnricherMockRecorder struct {
+	mock *MockEnricher
+}
+
+// NewMockEnricher creates a new mock instance.
+func NewMockEnricher(ctrl *gomock.Controller) *MockEnricher {
+	mock := &MockEnricher{ctrl: ctrl}
+	mock.recorder = &MockEnricherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEnricher) EXPECT() *MockEnricherMockRecorder {
+	return m.recorder
+}
+
+// GetAge mocks base method.
+func (m *MockEnricher) GetAge(ctx context.Context, name, countryId string) (*models.AgeEnrichment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAge", ctx, name, countryId)
+	ret0, _ := ret[0].(*models.AgeEnrichment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAge indicates an expected call of GetAge.
+func (mr *MockEnricherMockRecorder) GetAge(ctx, name, countryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAge", reflect.TypeOf((*MockEnricher)(nil).GetAge), ctx, name, countryId)
+}
+
+// GetAges mocks base method.
+func (m *MockEnricher) GetAges(ctx context.Context, names []string, countryId string) (map[string]*models.AgeEnrichment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAges", ctx, names, countryId)
+	ret0, _ := ret[0].(map[string]*models.AgeEnrichment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAges indicates an expected call of GetAges.
+func (mr *MockEnricherMockRecorder) GetAges(ctx, names, countryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAges", reflect.TypeOf((*MockEnricher)(nil).GetAges), ctx, names, countryId)
+}
+
+// GetGender mocks base method.
+func (m *MockEnricher) GetGender(ctx context.Context, name, countryId string) (*models.GenderEnrichment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGender", ctx, name, countryId)
+	ret0, _ := ret[0].(*models.GenderEnrichment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGender indicates an expected call of GetGender.
+func (mr *MockEnricherMockRecorder) GetGender(ctx, name, countryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGender", reflect.TypeOf((*MockEnricher)(nil).GetGender), ctx, name, countryId)
+}
+
+// GetGenders mocks base method.
+func (m *MockEnricher) GetGenders(ctx context.Context, names []string, countryId string) (map[string]*models.GenderEnrichment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenders", ctx, names, countryId)
+	ret0, _ := ret[0].(map[string]*models.GenderEnrichment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGenders indicates an expected call of GetGenders.
+func (mr *MockEnricherMockRecorder) GetGenders(ctx, names, countryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenders", reflect.TypeOf((*MockEnricher)(nil).GetGenders), ctx, names, countryId)
+}
+
+// GetNationalities mocks base method.
+func (m *MockEnricher) GetNationalities(ctx context.Context, names []string) (map[string]*models.NationalityEnrichment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNationalities", ctx, names)
+	ret0, _ := ret[0].(map[string]*models.NationalityEnrichment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNationalities indicates an expected call of GetNationalities.
+func (mr *MockEnricherMockRecorder) GetNationalities(ctx, names interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNationalities", reflect.TypeOf((*MockEnricher)(nil).GetNationalities), ctx, names)
+}
+
+// GetNationality mocks base method.
+func (m *MockEnricher) GetNationality(ctx context.Context, name string) (*models.NationalityEnrichment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNationality", ctx, name)
+	ret0, _ := ret[0].(*models.NationalityEnrichment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNationality indicates an expected call of GetNationality.
+func (mr *MockEnricherMockRecorder) GetNationality(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNationality", reflect.TypeOf((*MockEnricher)(nil).GetNationality), ctx, name)
+}
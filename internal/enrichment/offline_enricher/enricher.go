@@ -0,0 +1,236 @@
+package offline_enricher
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fio_finder/internal/enrichment"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const OfflineProvider = "offline"
+
+// nameStatistics is a single dataset entry. In CSV files every row holds one
+// country candidate, so a name spread over several rows gets all of them.
+type nameStatistics struct {
+	Name              string    `json:"name"`
+	Count             int64     `json:"count"`
+	Age               uint64    `json:"age"`
+	Gender            string    `json:"gender"`
+	GenderProbability float64   `json:"gender_probability"`
+	Countries         []country `json:"countries"`
+}
+
+type country struct {
+	CountryId   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+var csvHeader = []string{"name", "count", "age", "gender", "gender_probability", "country_id", "country_probability"}
+
+// OfflineEnricher serves worldwide statistics only, so country hints are
+// ignored.
+type OfflineEnricher struct {
+	statistics map[string]*nameStatistics
+}
+
+// NewOfflineEnricher loads name statistics from a .csv or .json file.
+func NewOfflineEnricher(path string) (enrichment.Enricher, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []nameStatistics
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&records)
+	case ".csv":
+		records, err = readCSV(f)
+	default:
+		err = fmt.Errorf("unsupported dataset format: %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statistics := make(map[string]*nameStatistics, len(records))
+	for i := range records {
+		key := normalizeName(records[i].Name)
+		if existing, ok := statistics[key]; ok {
+			existing.Countries = append(existing.Countries, records[i].Countries...)
+			continue
+		}
+		statistics[key] = &records[i]
+	}
+	return &OfflineEnricher{statistics: statistics}, nil
+}
+
+func readCSV(r io.Reader) ([]nameStatistics, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range csvHeader {
+		if strings.TrimSpace(header[i]) != csvHeader[i] {
+			return nil, fmt.Errorf("unexpected dataset header: %s", strings.Join(header, ","))
+		}
+	}
+
+	var records []nameStatistics
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		record := nameStatistics{Name: row[0], Gender: row[3]}
+		if record.Count, err = parseInt(row[1]); err != nil {
+			return nil, err
+		}
+		if record.Age, err = parseUint(row[2]); err != nil {
+			return nil, err
+		}
+		if record.GenderProbability, err = parseFloat(row[4]); err != nil {
+			return nil, err
+		}
+		if row[5] != "" {
+			probability, err := parseFloat(row[6])
+			if err != nil {
+				return nil, err
+			}
+			record.Countries = []country{{CountryId: row[5], Probability: probability}}
+		}
+		records = append(records, record)
+	}
+}
+
+func (e *OfflineEnricher) GetAge(_ context.Context, name string, _ string) (*models.AgeEnrichment, error) {
+	stats, ok := e.statistics[normalizeName(name)]
+	if !ok || stats.Age == 0 {
+		return nil, &enrichmentErrors.ProviderError{Provider: OfflineProvider, Err: enrichmentErrors.EmptyResult}
+	}
+	return stats.toAge(), nil
+}
+
+func (e *OfflineEnricher) GetGender(_ context.Context, name string, _ string) (*models.GenderEnrichment, error) {
+	stats, ok := e.statistics[normalizeName(name)]
+	if !ok || stats.Gender == "" {
+		return nil, &enrichmentErrors.ProviderError{Provider: OfflineProvider, Err: enrichmentErrors.EmptyResult}
+	}
+	return stats.toGender(), nil
+}
+
+func (e *OfflineEnricher) GetNationality(_ context.Context, name string) (*models.NationalityEnrichment, error) {
+	stats, ok := e.statistics[normalizeName(name)]
+	if !ok || len(stats.Countries) == 0 {
+		return nil, &enrichmentErrors.ProviderError{Provider: OfflineProvider, Err: enrichmentErrors.EmptyResult}
+	}
+	return stats.toNationality(), nil
+}
+
+func (e *OfflineEnricher) GetAges(_ context.Context, names []string, _ string) (map[string]*models.AgeEnrichment, error) {
+	res := make(map[string]*models.AgeEnrichment, len(names))
+	for _, name := range names {
+		if stats, ok := e.statistics[normalizeName(name)]; ok && stats.Age != 0 {
+			res[name] = stats.toAge()
+		}
+	}
+	return res, nil
+}
+
+func (e *OfflineEnricher) GetGenders(_ context.Context, names []string, _ string) (map[string]*models.GenderEnrichment, error) {
+	res := make(map[string]*models.GenderEnrichment, len(names))
+	for _, name := range names {
+		if stats, ok := e.statistics[normalizeName(name)]; ok && stats.Gender != "" {
+			res[name] = stats.toGender()
+		}
+	}
+	return res, nil
+}
+
+func (e *OfflineEnricher) GetNationalities(_ context.Context, names []string) (map[string]*models.NationalityEnrichment, error) {
+	res := make(map[string]*models.NationalityEnrichment, len(names))
+	for _, name := range names {
+		if stats, ok := e.statistics[normalizeName(name)]; ok && len(stats.Countries) != 0 {
+			res[name] = stats.toNationality()
+		}
+	}
+	return res, nil
+}
+
+func (s *nameStatistics) toAge() *models.AgeEnrichment {
+	return &models.AgeEnrichment{
+		Age:      s.Age,
+		Count:    s.Count,
+		Provider: OfflineProvider,
+	}
+}
+
+func (s *nameStatistics) toGender() *models.GenderEnrichment {
+	return &models.GenderEnrichment{
+		Gender:      models.PersonGender(strings.ToUpper(s.Gender[:1]) + strings.ToLower(s.Gender[1:])),
+		Probability: s.GenderProbability,
+		Count:       s.Count,
+		Provider:    OfflineProvider,
+	}
+}
+
+func (s *nameStatistics) toNationality() *models.NationalityEnrichment {
+	countries := make([]models.CountryProbability, 0, len(s.Countries))
+	for _, c := range s.Countries {
+		countries = append(countries, models.CountryProbability{
+			CountryId:   c.CountryId,
+			Probability: c.Probability,
+		})
+	}
+	// Callers take the first candidate as the most likely one.
+	sort.SliceStable(countries, func(i, j int) bool {
+		return countries[i].Probability > countries[j].Probability
+	})
+	return &models.NationalityEnrichment{
+		Countries: countries,
+		Count:     s.Count,
+		Provider:  OfflineProvider,
+	}
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func parseInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseUint(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func parseFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,63 @@
+package offline_enricher
+
+import (
+	"context"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDataset(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestOfflineEnricher_CSV(t *testing.T) {
+	t.Parallel()
+
+	path := writeDataset(t, "names.csv", `name,count,age,gender,gender_probability,country_id,country_probability
+Ivan,1000,45,male,0.99,UA,0.2
+Ivan,1000,45,male,0.99,RU,0.7
+`)
+	enricher, err := NewOfflineEnricher(path)
+	require.NoError(t, err)
+
+	age, err := enricher.GetAge(context.Background(), " IVAN", "RU")
+	require.NoError(t, err)
+	require.Equal(t, &models.AgeEnrichment{Age: 45, Count: 1000, Provider: OfflineProvider}, age)
+
+	nationality, err := enricher.GetNationality(context.Background(), "ivan")
+	require.NoError(t, err)
+	require.Equal(t, []models.CountryProbability{{CountryId: "RU", Probability: 0.7}, {CountryId: "UA", Probability: 0.2}}, nationality.Countries)
+
+	_, err = enricher.GetGender(context.Background(), "Aigerim", "")
+	require.ErrorIs(t, err, enrichmentErrors.EmptyResult)
+}
+
+func TestOfflineEnricher_JSON(t *testing.T) {
+	t.Parallel()
+
+	path := writeDataset(t, "names.json", `[
+  {"name": "Aigerim", "count": 500, "age": 30, "gender": "female", "gender_probability": 0.98,
+   "countries": [{"country_id": "KZ", "probability": 0.9}]}
+]`)
+	enricher, err := NewOfflineEnricher(path)
+	require.NoError(t, err)
+
+	genders, err := enricher.GetGenders(context.Background(), []string{"Aigerim", "Ivan"}, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]*models.GenderEnrichment{
+		"Aigerim": {Gender: models.FemaleUserGender, Probability: 0.98, Count: 500, Provider: OfflineProvider},
+	}, genders)
+}
+
+func TestOfflineEnricher_UnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewOfflineEnricher(writeDataset(t, "names.txt", ""))
+	require.Error(t, err)
+}
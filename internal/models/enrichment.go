@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+const (
+	EnrichedFieldAge         = "age"
+	EnrichedFieldGender      = "gender"
+	EnrichedFieldNationality = "nationality"
+
+	// ManualProvider marks fields set by a user rather than a provider.
+	ManualProvider = "manual"
+)
+
+// FieldEnrichment describes where an enriched person field came from and how
+// much the provider trusted it. Probability is nil when the provider does not
+// report one. CountryHint is the hint the provider was queried with.
+type FieldEnrichment struct {
+	Field       string
+	Provider    string
+	Probability *float64
+	Count       int64
+	CountryHint string
+	EnrichedAt  time.Time
+}
+
+type AgeEnrichment struct {
+	Age      uint64
+	Count    int64
+	Provider string
+}
+
+type GenderEnrichment struct {
+	Gender      PersonGender
+	Probability float64
+	Count       int64
+	Provider    string
+}
+
+type CountryProbability struct {
+	CountryId   string
+	Probability float64
+}
+
+type NationalityEnrichment struct {
+	Countries []CountryProbability
+	Count     int64
+	Provider  string
+}
+
+// ReEnrichmentStatus reports progress of the current or last background
+// re-enrichment run.
+type ReEnrichmentStatus struct {
+	Running    bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Processed  uint64
+	Enriched   uint64
+	Failed     uint64
+}
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// ProviderHealth reports the circuit breaker state of an enrichment provider.
+// OpenedAt is zero while the circuit has never been opened.
+type ProviderHealth struct {
+	Provider string
+	State    string
+	Failures int
+	OpenedAt time.Time
+}
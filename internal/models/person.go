@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type PersonField int
 type PersonFieldsToUpdate map[PersonField]any
 
@@ -10,6 +12,19 @@ const (
 	PersonFieldAge
 	PersonFieldGender
 	PersonFieldNationality
+	PersonFieldStatus
+)
+
+// PersonStatus tells how far a person got through enrichment. Persons
+// created with all fields given by a user are not enriched at all.
+type PersonStatus string
+
+const (
+	PersonStatusManual            = PersonStatus("manual")
+	PersonStatusPendingEnrichment = PersonStatus("pending_enrichment")
+	PersonStatusEnriched          = PersonStatus("enriched")
+	PersonStatusPartiallyEnriched = PersonStatus("partially_enriched")
+	PersonStatusEnrichmentFailed  = PersonStatus("failed")
 )
 
 type PersonGender string
@@ -20,11 +35,151 @@ const (
 )
 
 type Person struct {
-	Id          uint64
-	Name        string
-	Surname     string
-	Patronymic  string
-	Age         uint64
-	Gender      PersonGender
-	Nationality string
+	Id         uint64
+	Name       string
+	Surname    string
+	Patronymic string
+	// Age, Gender and Nationality are nil while unknown, either because the
+	// person was not enriched or because the provider knew nothing.
+	Age         *uint64
+	Gender      *PersonGender
+	Nationality *string
+	Status      PersonStatus
+	// CountryHint is an optional ISO 3166-1 alpha-2 code used to narrow
+	// down enrichment. It is kept with the enrichment results.
+	CountryHint string
+	Enrichments []FieldEnrichment
+	// NationalityCandidates holds every country the nationality provider
+	// suggested, ranked by probability. Nationality is the top candidate.
+	NationalityCandidates []CountryProbability
+	// ExternalId optionally identifies the person in the system it came
+	// from and can be part of the natural key.
+	ExternalId string
+	// SearchKey is the normalized "surname name patronymic" the repository
+	// keeps for searching and deduplication, see normalize.SearchKey.
+	SearchKey string
+	// Version grows with every change of the person and guards updates
+	// against overwriting changes made by someone else.
+	Version uint64
+}
+
+// PersonFilter narrows down, orders and pages the person list. Zero values
+// do not filter. MinNationalityProbability keeps persons having a nationality
+// candidate with at least that probability, limited to NationalityCandidate
+// if set, while Nationality matches the top candidate only. Name prefixes are
+// matched case-insensitively.
+type PersonFilter struct {
+	Status                    PersonStatus
+	NationalityCandidate      string
+	MinNationalityProbability *float64
+	MinAge                    *uint64
+	MaxAge                    *uint64
+	Gender                    PersonGender
+	Nationality               string
+	NamePrefix                string
+	SurnamePrefix             string
+	HasPatronymic             *bool
+
+	// SortField defaults to id and SortDirection to ascending. Ties are
+	// always broken by id.
+	SortField     PersonSortField
+	SortDirection SortDirection
+	// Limit defaults to DefaultPersonListLimit and may not exceed
+	// MaxPersonListLimit.
+	Limit  int
+	Offset int
+	// After is an opaque cursor from a previous page. The list continues
+	// right after the person it points to, so rows inserted meanwhile do not
+	// shift the page. It cannot be combined with Offset and is only valid
+	// for the sort order it was issued for.
+	After string
+}
+
+type PersonSortField string
+
+const (
+	PersonSortId          = PersonSortField("id")
+	PersonSortName        = PersonSortField("name")
+	PersonSortSurname     = PersonSortField("surname")
+	PersonSortPatronymic  = PersonSortField("patronymic")
+	PersonSortAge         = PersonSortField("age")
+	PersonSortGender      = PersonSortField("gender")
+	PersonSortNationality = PersonSortField("nationality")
+)
+
+type SortDirection string
+
+const (
+	SortAscending  = SortDirection("asc")
+	SortDescending = SortDirection("desc")
+)
+
+const (
+	DefaultPersonListLimit = 100
+	MaxPersonListLimit     = 1000
+)
+
+// PersonMatch is a search result. Score is the similarity between the
+// query and the person's names, from 0 to 1.
+type PersonMatch struct {
+	Person Person
+	Score  float64
+}
+
+const (
+	DefaultPersonSearchLimit = 20
+	MaxPersonSearchLimit     = 100
+)
+
+// MaxPersonBatchSize bounds the number of persons created at once.
+const MaxPersonBatchSize = 10000
+
+// PersonList is a page of the person list. Total counts every person
+// matching the filter regardless of paging. Cursors holds the cursor of
+// every listed person, NextCursor the one to continue with or "" on the last
+// page.
+type PersonList struct {
+	Persons    []Person
+	Total      uint64
+	Cursors    []string
+	NextCursor string
+}
+
+type PersonAction string
+
+const (
+	PersonActionCreate  = PersonAction("create")
+	PersonActionUpdate  = PersonAction("update")
+	PersonActionDelete  = PersonAction("delete")
+	PersonActionRestore = PersonAction("restore")
+	PersonActionPurge   = PersonAction("purge")
+)
+
+// Keys of the person values recorded in PersonChange.
+const (
+	PersonValueName        = "name"
+	PersonValueSurname     = "surname"
+	PersonValuePatronymic  = "patronymic"
+	PersonValueAge         = "age"
+	PersonValueGender      = "gender"
+	PersonValueNationality = "nationality"
+	PersonValueStatus      = "status"
+	PersonValueCountryHint = "country_hint"
+	PersonValueExternalId  = "external_id"
+)
+
+// PersonChange is an entry of the person history. OldValues and NewValues
+// are keyed by the PersonValue constants: a create has the whole person in NewValues, an
+// update the changed columns in both, a delete or purge the whole person in
+// OldValues and a restore in NewValues. Version is the person's version
+// after the change.
+type PersonChange struct {
+	Id        uint64
+	PersonId  uint64
+	Action    PersonAction
+	Actor     string
+	Version   uint64
+	ChangedAt time.Time
+	OldValues map[string]any
+	NewValues map[string]any
 }
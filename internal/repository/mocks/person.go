@@ -8,6 +8,7 @@ import (
 	context "context"
 	models "fio_finder/internal/models"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,21 @@ func (m *MockPersonRepository) EXPECT() *MockPersonRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimPending mocks base method.
+func (m *MockPersonRepository) ClaimPending(ctx context.Context, lease time.Duration, limit int) ([]models.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPending", ctx, lease, limit)
+	ret0, _ := ret[0].([]models.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPending indicates an expected call of ClaimPending.
+func (mr *MockPersonRepositoryMockRecorder) ClaimPending(ctx, lease, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPending", reflect.TypeOf((*MockPersonRepository)(nil).ClaimPending), ctx, lease, limit)
+}
+
 // Create mocks base method.
 func (m *MockPersonRepository) Create(ctx context.Context, person *models.Person) error {
 	m.ctrl.T.Helper()
@@ -49,6 +65,21 @@ func (mr *MockPersonRepositoryMockRecorder) Create(ctx, person interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPersonRepository)(nil).Create), ctx, person)
 }
 
+// CreateMany mocks base method.
+func (m *MockPersonRepository) CreateMany(ctx context.Context, persons []models.Person) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, persons)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockPersonRepositoryMockRecorder) CreateMany(ctx, persons interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockPersonRepository)(nil).CreateMany), ctx, persons)
+}
+
 // Delete mocks base method.
 func (m *MockPersonRepository) Delete(ctx context.Context, id uint64) error {
 	m.ctrl.T.Helper()
@@ -63,6 +94,21 @@ func (mr *MockPersonRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPersonRepository)(nil).Delete), ctx, id)
 }
 
+// ExistsByNaturalKey mocks base method.
+func (m *MockPersonRepository) ExistsByNaturalKey(ctx context.Context, person *models.Person) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsByNaturalKey", ctx, person)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsByNaturalKey indicates an expected call of ExistsByNaturalKey.
+func (mr *MockPersonRepositoryMockRecorder) ExistsByNaturalKey(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsByNaturalKey", reflect.TypeOf((*MockPersonRepository)(nil).ExistsByNaturalKey), ctx, person)
+}
+
 // Get mocks base method.
 func (m *MockPersonRepository) Get(ctx context.Context, id uint64) (*models.Person, error) {
 	m.ctrl.T.Helper()
@@ -78,31 +124,135 @@ func (mr *MockPersonRepositoryMockRecorder) Get(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPersonRepository)(nil).Get), ctx, id)
 }
 
+// GetHistory mocks base method.
+func (m *MockPersonRepository) GetHistory(ctx context.Context, id uint64) ([]models.PersonChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, id)
+	ret0, _ := ret[0].([]models.PersonChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockPersonRepositoryMockRecorder) GetHistory(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockPersonRepository)(nil).GetHistory), ctx, id)
+}
+
 // GetList mocks base method.
-func (m *MockPersonRepository) GetList(ctx context.Context) ([]models.Person, error) {
+func (m *MockPersonRepository) GetList(ctx context.Context, filter models.PersonFilter) (*models.PersonList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetList", ctx)
-	ret0, _ := ret[0].([]models.Person)
+	ret := m.ctrl.Call(m, "GetList", ctx, filter)
+	ret0, _ := ret[0].(*models.PersonList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetList indicates an expected call of GetList.
-func (mr *MockPersonRepositoryMockRecorder) GetList(ctx interface{}) *gomock.Call {
+func (mr *MockPersonRepositoryMockRecorder) GetList(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockPersonRepository)(nil).GetList), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockPersonRepository)(nil).GetList), ctx, filter)
 }
 
-// Update mocks base method.
-func (m *MockPersonRepository) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate) error {
+// GetStale mocks base method.
+func (m *MockPersonRepository) GetStale(ctx context.Context, enrichedBefore time.Time, afterId uint64, limit int) ([]models.Person, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, id, fieldsToUpdate)
+	ret := m.ctrl.Call(m, "GetStale", ctx, enrichedBefore, afterId, limit)
+	ret0, _ := ret[0].([]models.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStale indicates an expected call of GetStale.
+func (mr *MockPersonRepositoryMockRecorder) GetStale(ctx, enrichedBefore, afterId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStale", reflect.TypeOf((*MockPersonRepository)(nil).GetStale), ctx, enrichedBefore, afterId, limit)
+}
+
+// Purge mocks base method.
+func (m *MockPersonRepository) Purge(ctx context.Context, deletedBefore time.Time) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, deletedBefore)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockPersonRepositoryMockRecorder) Purge(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockPersonRepository)(nil).Purge), ctx, deletedBefore)
+}
+
+// Restore mocks base method.
+func (m *MockPersonRepository) Restore(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Restore indicates an expected call of Restore.
+func (mr *MockPersonRepositoryMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPersonRepository)(nil).Restore), ctx, id)
+}
+
+// Search mocks base method.
+func (m *MockPersonRepository) Search(ctx context.Context, query string, limit int) ([]models.PersonMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]models.PersonMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPersonRepositoryMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPersonRepository)(nil).Search), ctx, query, limit)
+}
+
+// Update mocks base method.
+func (m *MockPersonRepository) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, expectedVersion *uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, fieldsToUpdate, expectedVersion)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Update indicates an expected call of Update.
-func (mr *MockPersonRepositoryMockRecorder) Update(ctx, id, fieldsToUpdate interface{}) *gomock.Call {
+func (mr *MockPersonRepositoryMockRecorder) Update(ctx, id, fieldsToUpdate, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPersonRepository)(nil).Update), ctx, id, fieldsToUpdate, expectedVersion)
+}
+
+// UpdateEnrichment mocks base method.
+func (m *MockPersonRepository) UpdateEnrichment(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, enrichments []models.FieldEnrichment, nationalities []models.CountryProbability, expectedVersion *uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEnrichment", ctx, id, fieldsToUpdate, enrichments, nationalities, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEnrichment indicates an expected call of UpdateEnrichment.
+func (mr *MockPersonRepositoryMockRecorder) UpdateEnrichment(ctx, id, fieldsToUpdate, enrichments, nationalities, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEnrichment", reflect.TypeOf((*MockPersonRepository)(nil).UpdateEnrichment), ctx, id, fieldsToUpdate, enrichments, nationalities, expectedVersion)
+}
+
+// Upsert mocks base method.
+func (m *MockPersonRepository) Upsert(ctx context.Context, person *models.Person, policy string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, person, policy)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockPersonRepositoryMockRecorder) Upsert(ctx, person, policy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPersonRepository)(nil).Update), ctx, id, fieldsToUpdate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockPersonRepository)(nil).Upsert), ctx, person, policy)
 }
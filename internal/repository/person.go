@@ -3,13 +3,48 @@ package repository
 import (
 	"context"
 	"fio_finder/internal/models"
+	"time"
 )
 
 //go:generate mockgen -source=person.go -destination=mocks/person.go
 type PersonRepository interface {
 	Create(ctx context.Context, person *models.Person) error
+	// CreateMany saves all persons or none in a single transaction, filling
+	// in their ids and versions. It returns the ids in the order given.
+	CreateMany(ctx context.Context, persons []models.Person) ([]uint64, error)
+	// Upsert creates the person unless one with the same natural key
+	// exists, which is then changed according to the config.ConflictPolicy
+	// policy. It reports whether the person was created and fills in its id
+	// and version either way.
+	Upsert(ctx context.Context, person *models.Person, policy string) (bool, error)
+	// ExistsByNaturalKey tells whether a person that is not deleted shares
+	// the natural key of person.
+	ExistsByNaturalKey(ctx context.Context, person *models.Person) (bool, error)
+	// Delete hides the person from every other method until it is restored.
 	Delete(ctx context.Context, id uint64) error
-	Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate) error
+	Restore(ctx context.Context, id uint64) error
+	// Purge permanently removes persons deleted before deletedBefore and
+	// returns their number.
+	Purge(ctx context.Context, deletedBefore time.Time) (uint64, error)
+	// Update applies the change only if the person is still at
+	// expectedVersion, unless it is nil, and returns the new version.
+	Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, expectedVersion *uint64) (uint64, error)
+	// UpdateEnrichment stores enriched fields with their provenance. Nil
+	// nationalities keep the stored nationality candidates. Like Update, it
+	// only applies if the person is still at expectedVersion, unless it is
+	// nil, so a concurrent edit is not overwritten.
+	UpdateEnrichment(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, enrichments []models.FieldEnrichment,
+		nationalities []models.CountryProbability, expectedVersion *uint64) error
 	Get(ctx context.Context, id uint64) (*models.Person, error)
-	GetList(ctx context.Context) ([]models.Person, error)
+	// GetHistory returns the recorded changes of a person, oldest first,
+	// including those of purged persons.
+	GetHistory(ctx context.Context, id uint64) ([]models.PersonChange, error)
+	GetList(ctx context.Context, filter models.PersonFilter) (*models.PersonList, error)
+	// Search finds persons by partial or misspelled names, best matches
+	// first.
+	Search(ctx context.Context, query string, limit int) ([]models.PersonMatch, error)
+	GetStale(ctx context.Context, enrichedBefore time.Time, afterId uint64, limit int) ([]models.Person, error)
+	// ClaimPending hands out up to limit persons pending enrichment that are
+	// not claimed by someone else within the last lease.
+	ClaimPending(ctx context.Context, lease time.Duration, limit int) ([]models.Person, error)
 }
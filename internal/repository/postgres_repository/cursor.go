@@ -0,0 +1,100 @@
+package postgres_repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/repositoryErrors"
+	"strconv"
+	"strings"
+)
+
+// personCursor points at a row of the person list by its sort key and id.
+// It is handed out base64-encoded, so clients treat it as opaque.
+type personCursor struct {
+	SortField     models.PersonSortField `json:"f"`
+	SortDirection models.SortDirection   `json:"d"`
+	Value         *string                `json:"v,omitempty"`
+	Id            uint64                 `json:"i"`
+}
+
+func encodeCursor(person *PersonPostgres, sortField models.PersonSortField, direction models.SortDirection) string {
+	data, _ := json.Marshal(personCursor{
+		SortField:     sortField,
+		SortDirection: direction,
+		Value:         sortValue(person, sortField),
+		Id:            person.Id,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor rejects cursors that are malformed or were issued for a
+// different sort order.
+func decodeCursor(cursor string, sortField models.PersonSortField, direction models.SortDirection) (*personCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, repositoryErrors.InvalidCursor
+	}
+	var c personCursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, repositoryErrors.InvalidCursor
+	}
+	if c.SortField != sortField || c.SortDirection != direction {
+		return nil, repositoryErrors.InvalidCursor
+	}
+	return &c, nil
+}
+
+func sortValue(person *PersonPostgres, sortField models.PersonSortField) *string {
+	var value string
+	switch sortField {
+	case models.PersonSortName:
+		value = person.Name
+	case models.PersonSortSurname:
+		value = person.Surname
+	case models.PersonSortPatronymic:
+		value = person.Patronymic
+	case models.PersonSortAge:
+		if person.Age == nil {
+			return nil
+		}
+		value = strconv.FormatUint(*person.Age, 10)
+	case models.PersonSortGender:
+		if person.Gender == nil {
+			return nil
+		}
+		value = string(*person.Gender)
+	case models.PersonSortNationality:
+		if person.Nationality == nil {
+			return nil
+		}
+		value = *person.Nationality
+	default:
+		return nil
+	}
+	return &value
+}
+
+// keysetCondition selects the rows following the cursor in the list order,
+// which puts nulls last in both directions. The placeholders continue after
+// the given number of arguments.
+func keysetCondition(c *personCursor, column string, direction models.SortDirection, argCount int) (string, []any) {
+	compare := ">"
+	if direction == models.SortDescending {
+		compare = "<"
+	}
+	next := func(n int) string { return "$" + strconv.Itoa(argCount+n) }
+
+	if column == "id" {
+		return "id " + compare + " " + next(1), []any{c.Id}
+	}
+	if c.Value == nil {
+		return column + " is null and id " + compare + " " + next(1), []any{c.Id}
+	}
+	condition := strings.Join([]string{
+		column + " " + compare + " " + next(1),
+		"(" + column + " = " + next(1) + " and id " + compare + " " + next(2) + ")",
+		column + " is null",
+	}, " or ")
+	return "(" + condition + ")", []any{*c.Value, c.Id}
+}
@@ -6,8 +6,8 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-func CreatePersonPostgresRepository(db *sql.DB) repository.PersonRepository {
+func CreatePersonPostgresRepository(db *sql.DB, naturalKey []string) repository.PersonRepository {
 	dbx := sqlx.NewDb(db, "pgx")
 
-	return NewPersonPostgresRepository(dbx)
+	return NewPersonPostgresRepository(dbx, naturalKey)
 }
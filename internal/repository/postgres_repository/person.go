@@ -7,20 +7,50 @@ import (
 	"fio_finder/internal/models"
 	"fio_finder/internal/repository"
 	"fio_finder/pkg/errors/repositoryErrors"
+	"fio_finder/pkg/normalize"
 	"fio_finder/pkg/queries"
 	"github.com/jinzhu/copier"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
 type PersonPostgres struct {
-	Id          uint64              `db:"id"`
-	Name        string              `db:"name"`
-	Surname     string              `db:"surname"`
-	Patronymic  string              `db:"patronymic"`
-	Gender      models.PersonGender `db:"gender"`
-	Age         uint64              `db:"age"`
-	Nationality string              `db:"nationality"`
+	Id          uint64               `db:"id"`
+	Name        string               `db:"name"`
+	Surname     string               `db:"surname"`
+	Patronymic  string               `db:"patronymic"`
+	Gender      *models.PersonGender `db:"gender"`
+	Age         *uint64              `db:"age"`
+	Nationality *string              `db:"nationality"`
+	Status      models.PersonStatus  `db:"status"`
+	CountryHint string               `db:"country_hint"`
+	ExternalId  string               `db:"external_id"`
+	SearchKey   string               `db:"search_key"`
+	NaturalKey  *string              `db:"natural_key"`
+	DeletedAt   *time.Time           `db:"deleted_at"`
+	Version     uint64               `db:"version"`
+
+	EnrichmentClaimedAt *time.Time `db:"enrichment_claimed_at"`
+}
+
+type PersonNationalityPostgres struct {
+	PersonId    uint64  `db:"person_id"`
+	CountryId   string  `db:"country_id"`
+	Probability float64 `db:"probability"`
+}
+
+type PersonEnrichmentPostgres struct {
+	PersonId    uint64    `db:"person_id"`
+	Field       string    `db:"field"`
+	Provider    string    `db:"provider"`
+	Probability *float64  `db:"probability"`
+	Count       int64     `db:"sample_count"`
+	CountryHint string    `db:"country_hint"`
+	EnrichedAt  time.Time `db:"enriched_at"`
 }
 
 var personFieldToDBField = map[models.PersonField]string{
@@ -30,72 +60,376 @@ var personFieldToDBField = map[models.PersonField]string{
 	models.PersonFieldAge:         "age",
 	models.PersonFieldGender:      "gender",
 	models.PersonFieldNationality: "nationality",
+	models.PersonFieldStatus:      "status",
+}
+
+var personFieldToEnrichedField = map[models.PersonField]string{
+	models.PersonFieldAge:         models.EnrichedFieldAge,
+	models.PersonFieldGender:      models.EnrichedFieldGender,
+	models.PersonFieldNationality: models.EnrichedFieldNationality,
 }
 
 type PersonPostgresRepository struct {
 	db *sqlx.DB
+	// naturalKey lists the parts of the natural key, see
+	// config.PersonsConfig. Without parts persons have no natural key.
+	naturalKey []string
 }
 
-func NewPersonPostgresRepository(db *sqlx.DB) repository.PersonRepository {
-	return &PersonPostgresRepository{db: db}
+func NewPersonPostgresRepository(db *sqlx.DB, naturalKey []string) repository.PersonRepository {
+	return &PersonPostgresRepository{db: db, naturalKey: naturalKey}
 }
 
 func (p *PersonPostgresRepository) Create(ctx context.Context, person *models.Person) error {
-	query := `insert into service.persons (name, surname, patronymic, age, gender, nationality) values
-											 ($1, $2, $3, $4, $5, $6);`
-	_, err := p.db.ExecContext(ctx, query, person.Name, person.Surname, person.Patronymic, person.Age,
-		person.Gender, person.Nationality)
+	tx, err := p.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	incoming := p.toPersonPostgres(person)
+	query := `insert into service.persons (name, surname, patronymic, age, gender, nationality, status, country_hint,
+					external_id, search_key, natural_key) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) returning *;`
+	var created PersonPostgres
+	err = tx.GetContext(ctx, &created, query, incoming.Name, incoming.Surname, incoming.Patronymic, incoming.Age,
+		incoming.Gender, incoming.Nationality, incoming.Status, incoming.CountryHint, incoming.ExternalId,
+		incoming.SearchKey, incoming.NaturalKey)
+	if err != nil {
+		return duplicateError(err)
+	}
+
+	if err = p.saveCreated(ctx, tx, &created, person); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	person.Id, person.Version = created.Id, created.Version
 	return nil
 }
 
+// saveCreated records the creation of a person inserted within tx and
+// saves what was found out about it.
+func (p *PersonPostgresRepository) saveCreated(ctx context.Context, tx *sqlx.Tx, created *PersonPostgres, person *models.Person) error {
+	if err := recordChange(ctx, tx, created, models.PersonActionCreate, nil, personValues(created)); err != nil {
+		return err
+	}
+	if err := saveEnrichments(ctx, tx, created.Id, person.Enrichments); err != nil {
+		return err
+	}
+	return saveNationalities(ctx, tx, created.Id, person.NationalityCandidates)
+}
+
+func saveEnrichments(ctx context.Context, tx *sqlx.Tx, personId uint64, enrichments []models.FieldEnrichment) error {
+	query := `insert into service.person_enrichments (person_id, field, provider, probability, sample_count, country_hint, enriched_at)
+				values ($1, $2, $3, $4, $5, $6, $7)
+				on conflict (person_id, field) do update set provider = excluded.provider,
+					probability = excluded.probability, sample_count = excluded.sample_count,
+					country_hint = excluded.country_hint, enriched_at = excluded.enriched_at;`
+	for _, e := range enrichments {
+		_, err := tx.ExecContext(ctx, query, personId, e.Field, e.Provider, e.Probability, e.Count, e.CountryHint, e.EnrichedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveNationalities replaces the stored nationality candidates of a person.
+// Nil candidates leave the stored ones untouched.
+func saveNationalities(ctx context.Context, tx *sqlx.Tx, personId uint64, nationalities []models.CountryProbability) error {
+	if nationalities == nil {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `delete from service.person_nationalities where person_id = $1;`, personId); err != nil {
+		return err
+	}
+
+	query := `insert into service.person_nationalities (person_id, country_id, probability) values ($1, $2, $3);`
+	for _, n := range nationalities {
+		if _, err := tx.ExecContext(ctx, query, personId, n.CountryId, n.Probability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PersonPostgresRepository) getNationalities(ctx context.Context, personIds []uint64) (map[uint64][]models.CountryProbability, error) {
+	query := `select * from service.person_nationalities where person_id = any($1)
+				order by person_id, probability desc, country_id;`
+
+	var nationalitiesPostgres []PersonNationalityPostgres
+	err := p.db.SelectContext(ctx, &nationalitiesPostgres, query, pq.Array(personIds))
+	if err != nil {
+		return nil, err
+	}
+
+	nationalities := make(map[uint64][]models.CountryProbability, len(personIds))
+	for _, n := range nationalitiesPostgres {
+		nationalities[n.PersonId] = append(nationalities[n.PersonId], models.CountryProbability{
+			CountryId:   n.CountryId,
+			Probability: n.Probability,
+		})
+	}
+	return nationalities, nil
+}
+
+func (p *PersonPostgresRepository) getEnrichments(ctx context.Context, personIds []uint64) (map[uint64][]models.FieldEnrichment, error) {
+	query := `select * from service.person_enrichments where person_id = any($1) order by person_id, field;`
+
+	var enrichmentsPostgres []PersonEnrichmentPostgres
+	err := p.db.SelectContext(ctx, &enrichmentsPostgres, query, pq.Array(personIds))
+	if err != nil {
+		return nil, err
+	}
+
+	enrichments := make(map[uint64][]models.FieldEnrichment, len(personIds))
+	for _, e := range enrichmentsPostgres {
+		enrichments[e.PersonId] = append(enrichments[e.PersonId], models.FieldEnrichment{
+			Field:       e.Field,
+			Provider:    e.Provider,
+			Probability: e.Probability,
+			Count:       e.Count,
+			CountryHint: e.CountryHint,
+			EnrichedAt:  e.EnrichedAt,
+		})
+	}
+	return enrichments, nil
+}
+
+// Delete only marks the person as deleted. It stays restorable until Purge
+// removes it.
 func (p *PersonPostgresRepository) Delete(ctx context.Context, id uint64) error {
-	query := `delete from service.persons where id = $1`
-	res, err := p.db.ExecContext(ctx, query, id)
-	count, _ := res.RowsAffected()
-	if count == 0 || errors.Is(err, sql.ErrNoRows) {
+	query := `update service.persons set deleted_at = now() where id = $1 and deleted_at is null returning *;`
+	return p.changeDeleted(ctx, query, id, models.PersonActionDelete)
+}
+
+func (p *PersonPostgresRepository) Restore(ctx context.Context, id uint64) error {
+	query := `update service.persons set deleted_at = null where id = $1 and deleted_at is not null returning *;`
+	return p.changeDeleted(ctx, query, id, models.PersonActionRestore)
+}
+
+// changeDeleted runs a query flipping the deleted mark of a person and
+// records it: the person goes to the old values on delete and to the new
+// ones on restore.
+func (p *PersonPostgresRepository) changeDeleted(ctx context.Context, query string, id uint64, action models.PersonAction) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var person PersonPostgres
+	err = tx.GetContext(ctx, &person, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
 		return repositoryErrors.ObjectDoesNotExists
 	} else if err != nil {
+		// A person restored after a duplicate was created clashes with it.
+		return duplicateError(err)
+	}
+
+	oldValues, newValues := personValues(&person), map[string]any(nil)
+	if action == models.PersonActionRestore {
+		oldValues, newValues = nil, oldValues
+	}
+	if err = recordChange(ctx, tx, &person, action, oldValues, newValues); err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit()
+}
+
+func (p *PersonPostgresRepository) Purge(ctx context.Context, deletedBefore time.Time) (uint64, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var purged []PersonPostgres
+	err = tx.SelectContext(ctx, &purged, `delete from service.persons where deleted_at < $1 returning *;`, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	for i := range purged {
+		if err = recordChange(ctx, tx, &purged[i], models.PersonActionPurge, personValues(&purged[i]), nil); err != nil {
+			return 0, err
+		}
+	}
+	return uint64(len(purged)), tx.Commit()
+}
+
+// Update changes person fields on behalf of a user. Enrichable fields changed
+// this way are marked as manually edited, so background re-enrichment leaves
+// them alone.
+func (p *PersonPostgresRepository) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, expectedVersion *uint64) (uint64, error) {
+	now := time.Now()
+	var enrichments []models.FieldEnrichment
+	for key := range fieldsToUpdate {
+		if field, ok := personFieldToEnrichedField[key]; ok {
+			enrichments = append(enrichments, models.FieldEnrichment{
+				Field:      field,
+				Provider:   models.ManualProvider,
+				EnrichedAt: now,
+			})
+		}
+	}
+
+	return p.update(ctx, id, fieldsToUpdate, enrichments, nil, expectedVersion)
 }
 
-func (p *PersonPostgresRepository) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate) error {
+func (p *PersonPostgresRepository) UpdateEnrichment(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate,
+	enrichments []models.FieldEnrichment, nationalities []models.CountryProbability, expectedVersion *uint64) error {
 	if len(fieldsToUpdate) == 0 {
 		return nil
 	}
+	_, err := p.update(ctx, id, fieldsToUpdate, enrichments, nationalities, expectedVersion)
+	return err
+}
+
+// update changes the fields and bumps the version of a person, recording
+// the old and new values of the changed columns. With an expected version
+// the change only applies if the stored version matches. It returns the
+// version after the change.
+func (p *PersonPostgresRepository) update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate,
+	enrichments []models.FieldEnrichment, nationalities []models.CountryProbability, expectedVersion *uint64) (uint64, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	old, err := lockPerson(ctx, tx, id, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+
 	updateFields := make(map[string]any, len(fieldsToUpdate))
 	for key, value := range fieldsToUpdate {
 		field, err := personFieldToDBField[key]
 		if !err {
-			return repositoryErrors.InvalidField
+			return 0, repositoryErrors.InvalidField
 		}
 		updateFields[field] = value
 	}
 
+	version, err := p.updateLocked(ctx, tx, old, updateFields, enrichments, nationalities)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// updateLocked changes the columns of a person locked within tx, keeping its
+// keys up to date, and records the change. It returns the version after the
+// change, which stays the same without columns to change.
+func (p *PersonPostgresRepository) updateLocked(ctx context.Context, tx *sqlx.Tx, old *PersonPostgres, updateFields map[string]any,
+	enrichments []models.FieldEnrichment, nationalities []models.CountryProbability) (uint64, error) {
+	if len(updateFields) == 0 {
+		return old.Version, nil
+	}
+
 	query, fields := queries.CreateSQLUpdateQuery("service.persons", updateFields)
+	fields = append(fields, old.Id)
+	query += `, version = version + 1 where id = $` + strconv.Itoa(len(fields)) + ` returning *;`
+
+	var updated PersonPostgres
+	if err := tx.GetContext(ctx, &updated, query, fields...); err != nil {
+		return 0, err
+	}
 
-	fields = append(fields, id)
-	query += ` where id = $` + strconv.Itoa(len(fields)) + ";"
+	if changesKeys(updateFields) {
+		updated.SearchKey = normalize.SearchKey(updated.Surname, updated.Name, updated.Patronymic)
+		updated.NaturalKey = p.naturalKeyOf(&updated)
+		_, err := tx.ExecContext(ctx, `update service.persons set search_key = $1, natural_key = $2 where id = $3;`,
+			updated.SearchKey, updated.NaturalKey, updated.Id)
+		if err != nil {
+			return 0, duplicateError(err)
+		}
+	}
+	if err := saveEnrichments(ctx, tx, updated.Id, enrichments); err != nil {
+		return 0, err
+	}
+	if err := saveNationalities(ctx, tx, updated.Id, nationalities); err != nil {
+		return 0, err
+	}
 
-	res, err := p.db.ExecContext(ctx, query, fields...)
+	err := recordChange(ctx, tx, &updated, models.PersonActionUpdate,
+		selectValues(personValues(old), updateFields), selectValues(personValues(&updated), updateFields))
 	if err != nil {
-		return err
+		return 0, err
 	}
-	count, _ := res.RowsAffected()
-	if count == 0 || errors.Is(err, sql.ErrNoRows) {
-		return repositoryErrors.ObjectDoesNotExists
+	return updated.Version, nil
+}
+
+// lockPerson returns a person locked for the rest of tx, failing with a
+// VersionConflictError if its version differs from the expected one.
+func lockPerson(ctx context.Context, tx *sqlx.Tx, id uint64, expectedVersion *uint64) (*PersonPostgres, error) {
+	var person PersonPostgres
+	err := tx.GetContext(ctx, &person, `select * from service.persons where id = $1 and deleted_at is null for update;`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositoryErrors.ObjectDoesNotExists
 	} else if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	if expectedVersion != nil && *expectedVersion != person.Version {
+		return nil, &repositoryErrors.VersionConflictError{Expected: *expectedVersion, Actual: person.Version}
+	}
+	return &person, nil
+}
+
+// changesKeys tells whether changing the columns affects the search or the
+// natural key.
+func changesKeys(updateFields map[string]any) bool {
+	for _, column := range []string{models.PersonValueName, models.PersonValueSurname, models.PersonValuePatronymic,
+		models.PersonValueExternalId} {
+		if _, ok := updateFields[column]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStale returns persons with id above afterId that miss an enrichment
+// record for age, gender or nationality, or whose record was made by a
+// provider before enrichedBefore. Manually edited fields never count as stale.
+func (p *PersonPostgresRepository) GetStale(ctx context.Context, enrichedBefore time.Time, afterId uint64, limit int) ([]models.Person, error) {
+	query := `select p.* from service.persons p
+				where p.id > $1 and p.deleted_at is null and p.status <> 'pending_enrichment' and exists (
+					select 1 from unnest(array['age', 'gender', 'nationality']) f(field)
+					left join service.person_enrichments e on e.person_id = p.id and e.field = f.field
+					where e.person_id is null or (e.provider <> $2 and e.enriched_at < $3)
+				)
+				order by p.id limit $4;`
+
+	var personsPostgres []PersonPostgres
+	err := p.db.SelectContext(ctx, &personsPostgres, query, afterId, models.ManualProvider, enrichedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	return p.toPersons(ctx, personsPostgres)
+}
+
+func (p *PersonPostgresRepository) ClaimPending(ctx context.Context, lease time.Duration, limit int) ([]models.Person, error) {
+	query := `update service.persons set enrichment_claimed_at = now()
+				where id in (
+					select id from service.persons
+					where status = 'pending_enrichment' and deleted_at is null
+						and (enrichment_claimed_at is null or enrichment_claimed_at < now() - $1 * interval '1 second')
+					order by id limit $2
+					for update skip locked
+				)
+				returning *;`
+
+	var personsPostgres []PersonPostgres
+	err := p.db.SelectContext(ctx, &personsPostgres, query, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return p.toPersons(ctx, personsPostgres)
 }
 
 func (p *PersonPostgresRepository) Get(ctx context.Context, id uint64) (*models.Person, error) {
-	query := `select * from service.persons where id = $1`
+	query := `select * from service.persons where id = $1 and deleted_at is null;`
 	personPostgres := &PersonPostgres{}
 
 	err := p.db.GetContext(ctx, personPostgres, query, id)
@@ -110,27 +444,264 @@ func (p *PersonPostgresRepository) Get(ctx context.Context, id uint64) (*models.
 		return nil, err
 	}
 
+	enrichments, err := p.getEnrichments(ctx, []uint64{id})
+	if err != nil {
+		return nil, err
+	}
+	person.Enrichments = enrichments[id]
+
+	nationalities, err := p.getNationalities(ctx, []uint64{id})
+	if err != nil {
+		return nil, err
+	}
+	person.NationalityCandidates = nationalities[id]
+
 	return person, nil
 }
 
-func (p *PersonPostgresRepository) GetList(ctx context.Context) ([]models.Person, error) {
-	query := `select * from service.persons order by id;`
+var personSortToDBField = map[models.PersonSortField]string{
+	models.PersonSortId:          "id",
+	models.PersonSortName:        "name",
+	models.PersonSortSurname:     "surname",
+	models.PersonSortPatronymic:  "patronymic",
+	models.PersonSortAge:         "age",
+	models.PersonSortGender:      "gender",
+	models.PersonSortNationality: "nationality",
+}
+
+var sortDirectionToSQL = map[models.SortDirection]string{
+	models.SortAscending:  "asc nulls last",
+	models.SortDescending: "desc nulls last",
+}
+
+func (p *PersonPostgresRepository) GetList(ctx context.Context, filter models.PersonFilter) (*models.PersonList, error) {
+	sortField, ok := personSortToDBField[filter.SortField]
+	if !ok {
+		return nil, repositoryErrors.InvalidFilter
+	}
+	direction, ok := sortDirectionToSQL[filter.SortDirection]
+	if !ok {
+		return nil, repositoryErrors.InvalidFilter
+	}
+
+	conditions, args := personListConditions(filter)
+	where := ` where ` + strings.Join(conditions, " and ")
+
+	list := &models.PersonList{}
+	err := p.db.GetContext(ctx, &list.Total, `select count(*) from service.persons`+where+`;`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.After != "" {
+		cursor, err := decodeCursor(filter.After, filter.SortField, filter.SortDirection)
+		if err != nil {
+			return nil, err
+		}
+		condition, cursorArgs := keysetCondition(cursor, sortField, filter.SortDirection, len(args))
+		args = append(args, cursorArgs...)
+		conditions = append(conditions, condition)
+		where = ` where ` + strings.Join(conditions, " and ")
+	}
+
+	query := `select * from service.persons` + where + ` order by ` + sortField + ` ` + direction
+	if sortField != "id" {
+		query += `, id ` + direction
+	}
+	// One row more than requested tells whether another page follows.
+	args = append(args, filter.Limit+1, filter.Offset)
+	query += ` limit $` + strconv.Itoa(len(args)-1) + ` offset $` + strconv.Itoa(len(args)) + `;`
 
 	var personsPostgres []PersonPostgres
-	var persons []models.Person
-	err := p.db.SelectContext(ctx, &personsPostgres, query)
+	err = p.db.SelectContext(ctx, &personsPostgres, query, args...)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, repositoryErrors.ObjectDoesNotExists
 	} else if err != nil {
 		return nil, err
 	}
 
+	hasMore := len(personsPostgres) > filter.Limit
+	if hasMore {
+		personsPostgres = personsPostgres[:filter.Limit]
+	}
+	list.Cursors = make([]string, 0, len(personsPostgres))
+	for i := range personsPostgres {
+		list.Cursors = append(list.Cursors, encodeCursor(&personsPostgres[i], filter.SortField, filter.SortDirection))
+	}
+	if hasMore {
+		list.NextCursor = list.Cursors[len(list.Cursors)-1]
+	}
+
+	list.Persons, err = p.toPersons(ctx, personsPostgres)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// personListConditions turns the filter into where conditions with numbered
+// placeholders matching the returned arguments. Deleted persons are always
+// left out.
+func personListConditions(filter models.PersonFilter) ([]string, []any) {
+	var (
+		conditions = []string{`deleted_at is null`}
+		args       []any
+	)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", `$`+strconv.Itoa(len(args))))
+	}
+
+	if filter.Status != "" {
+		addCondition(`status = $?`, filter.Status)
+	}
+	if filter.MinNationalityProbability != nil || filter.NationalityCandidate != "" {
+		condition := `exists (select 1 from service.person_nationalities n where n.person_id = persons.id`
+		if filter.MinNationalityProbability != nil {
+			args = append(args, *filter.MinNationalityProbability)
+			condition += ` and n.probability >= $` + strconv.Itoa(len(args))
+		}
+		if filter.NationalityCandidate != "" {
+			args = append(args, filter.NationalityCandidate)
+			condition += ` and n.country_id = $` + strconv.Itoa(len(args))
+		}
+		conditions = append(conditions, condition+`)`)
+	}
+	if filter.MinAge != nil {
+		addCondition(`age >= $?`, *filter.MinAge)
+	}
+	if filter.MaxAge != nil {
+		addCondition(`age <= $?`, *filter.MaxAge)
+	}
+	if filter.Gender != "" {
+		addCondition(`gender = $?`, filter.Gender)
+	}
+	if filter.Nationality != "" {
+		addCondition(`nationality = $?`, filter.Nationality)
+	}
+	if filter.NamePrefix != "" {
+		addCondition(`lower(name) like $? escape '\'`, likePrefix(filter.NamePrefix))
+	}
+	if filter.SurnamePrefix != "" {
+		addCondition(`lower(surname) like $? escape '\'`, likePrefix(filter.SurnamePrefix))
+	}
+	if filter.HasPatronymic != nil {
+		if *filter.HasPatronymic {
+			conditions = append(conditions, `patronymic <> ''`)
+		} else {
+			conditions = append(conditions, `patronymic = ''`)
+		}
+	}
+	return conditions, args
+}
+
+// likePrefix escapes the pattern characters of a prefix for a like match.
+func likePrefix(prefix string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(strings.ToLower(prefix)) + "%"
+}
+
+// searchSimilarityThreshold is the word similarity a person's search key
+// needs to match a query. It is below the pg_trgm default so that a single
+// typo in a short name still matches.
+const searchSimilarityThreshold = 0.4
+
+type PersonMatchPostgres struct {
+	PersonPostgres
+	Score float64 `db:"score"`
+}
+
+// Search matches the normalized query against search keys both by trigram
+// word similarity, which tolerates typos, and by word prefixes, which finds
+// partially typed names.
+func (p *PersonPostgresRepository) Search(ctx context.Context, query string, limit int) ([]models.PersonMatch, error) {
+	key := normalize.Key(query)
+	prefixes := prefixTsQuery(key)
+	if prefixes == "" {
+		return []models.PersonMatch{}, nil
+	}
+
+	tx, err := p.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `select set_config('pg_trgm.word_similarity_threshold', $1, true);`,
+		strconv.FormatFloat(searchSimilarityThreshold, 'f', -1, 64))
+	if err != nil {
+		return nil, err
+	}
+
+	searchQuery := `select p.*, word_similarity($1, p.search_key) as score from service.persons p
+				where p.deleted_at is null
+					and ($1 <% p.search_key or to_tsvector('simple', p.search_key) @@ to_tsquery('simple', $2))
+				order by score desc, p.id limit $3;`
+
+	var matchesPostgres []PersonMatchPostgres
+	err = tx.SelectContext(ctx, &matchesPostgres, searchQuery, key, prefixes, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	personsPostgres := make([]PersonPostgres, 0, len(matchesPostgres))
+	for i := range matchesPostgres {
+		personsPostgres = append(personsPostgres, matchesPostgres[i].PersonPostgres)
+	}
+	persons, err := p.toPersons(ctx, personsPostgres)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]models.PersonMatch, 0, len(persons))
+	for i := range persons {
+		matches = append(matches, models.PersonMatch{Person: persons[i], Score: matchesPostgres[i].Score})
+	}
+	return matches, tx.Commit()
+}
+
+// prefixTsQuery builds a tsquery matching every word of the key as a prefix.
+// Characters with a meaning in tsquery syntax are dropped.
+func prefixTsQuery(key string) string {
+	var terms []string
+	for _, word := range strings.Fields(key) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, word)
+		if word != "" {
+			terms = append(terms, word+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+func (p *PersonPostgresRepository) toPersons(ctx context.Context, personsPostgres []PersonPostgres) ([]models.Person, error) {
+	var persons []models.Person
+
+	ids := make([]uint64, 0, len(personsPostgres))
+	for i := range personsPostgres {
+		ids = append(ids, personsPostgres[i].Id)
+	}
+	enrichments, err := p.getEnrichments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	nationalities, err := p.getNationalities(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	for i := range personsPostgres {
 		person := &models.Person{}
 		err = copier.Copy(person, &personsPostgres[i])
 		if err != nil {
 			return nil, err
 		}
+		person.Enrichments = enrichments[person.Id]
+		person.NationalityCandidates = nationalities[person.Id]
 		persons = append(persons, *person)
 	}
 	return persons, nil
@@ -0,0 +1,114 @@
+package postgres_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/actor"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// firstVersion is the version of a newly created person.
+const firstVersion = 1
+
+// CreateMany copies the persons and everything recorded with them into the
+// tables with COPY. Ids are taken from the persons sequence up front, as
+// COPY cannot return them.
+func (p *PersonPostgresRepository) CreateMany(ctx context.Context, persons []models.Person) ([]uint64, error) {
+	if len(persons) == 0 {
+		return []uint64{}, nil
+	}
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var ids []uint64
+	err = tx.SelectContext(ctx, &ids, `select nextval(pg_get_serial_sequence('service.persons', 'id'))
+				from generate_series(1, $1);`, len(persons))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		personRows      = make([][]any, 0, len(persons))
+		enrichmentRows  [][]any
+		nationalityRows [][]any
+		historyRows     = make([][]any, 0, len(persons))
+		changedBy       = actor.FromContext(ctx)
+	)
+	for i := range persons {
+		person := &persons[i]
+		created := p.toPersonPostgres(person)
+		created.Id, created.Version = ids[i], firstVersion
+		personRows = append(personRows, []any{created.Id, created.Name, created.Surname, created.Patronymic, created.Age,
+			created.Gender, created.Nationality, created.Status, created.CountryHint, created.ExternalId,
+			created.SearchKey, created.NaturalKey, created.Version})
+
+		for _, e := range person.Enrichments {
+			enrichmentRows = append(enrichmentRows, []any{created.Id, e.Field, e.Provider, e.Probability, e.Count, e.CountryHint, e.EnrichedAt})
+		}
+		for _, n := range person.NationalityCandidates {
+			nationalityRows = append(nationalityRows, []any{created.Id, n.CountryId, n.Probability})
+		}
+
+		newValues, err := json.Marshal(personValues(&created))
+		if err != nil {
+			return nil, err
+		}
+		historyRows = append(historyRows, []any{created.Id, models.PersonActionCreate, changedBy, created.Version, string(newValues)})
+	}
+
+	err = copyRows(ctx, tx, "persons", []string{"id", "name", "surname", "patronymic", "age", "gender", "nationality",
+		"status", "country_hint", "external_id", "search_key", "natural_key", "version"}, personRows)
+	if err != nil {
+		return nil, duplicateError(err)
+	}
+	err = copyRows(ctx, tx, "person_enrichments", []string{"person_id", "field", "provider", "probability",
+		"sample_count", "country_hint", "enriched_at"}, enrichmentRows)
+	if err != nil {
+		return nil, err
+	}
+	err = copyRows(ctx, tx, "person_nationalities", []string{"person_id", "country_id", "probability"}, nationalityRows)
+	if err != nil {
+		return nil, err
+	}
+	err = copyRows(ctx, tx, "person_history", []string{"person_id", "action", "actor", "version", "new_values"}, historyRows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	for i := range persons {
+		persons[i].Id, persons[i].Version = ids[i], firstVersion
+	}
+	return ids, nil
+}
+
+// copyRows streams rows into a table of the service schema with COPY.
+func copyRows(ctx context.Context, tx *sqlx.Tx, table string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyInSchema("service", table, columns...))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return err
+	}
+	return stmt.Close()
+}
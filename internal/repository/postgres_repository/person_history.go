@@ -0,0 +1,126 @@
+package postgres_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/actor"
+	"fio_finder/pkg/errors/repositoryErrors"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type PersonChangePostgres struct {
+	Id        uint64              `db:"id"`
+	PersonId  uint64              `db:"person_id"`
+	Action    models.PersonAction `db:"action"`
+	Actor     string              `db:"actor"`
+	Version   uint64              `db:"version"`
+	ChangedAt time.Time           `db:"changed_at"`
+	OldValues []byte              `db:"old_values"`
+	NewValues []byte              `db:"new_values"`
+}
+
+// personValues returns the recorded columns of a person. Search key,
+// version and bookkeeping columns are derived and left out.
+func personValues(person *PersonPostgres) map[string]any {
+	return map[string]any{
+		models.PersonValueName:        person.Name,
+		models.PersonValueSurname:     person.Surname,
+		models.PersonValuePatronymic:  person.Patronymic,
+		models.PersonValueAge:         person.Age,
+		models.PersonValueGender:      person.Gender,
+		models.PersonValueNationality: person.Nationality,
+		models.PersonValueStatus:      person.Status,
+		models.PersonValueCountryHint: person.CountryHint,
+		models.PersonValueExternalId:  person.ExternalId,
+	}
+}
+
+// selectValues keeps the given columns of values only.
+func selectValues(values map[string]any, columns map[string]any) map[string]any {
+	selected := make(map[string]any, len(columns))
+	for column := range columns {
+		selected[column] = values[column]
+	}
+	return selected
+}
+
+// recordChange adds an entry to the person history within tx, attributed to
+// the actor of ctx.
+func recordChange(ctx context.Context, tx *sqlx.Tx, person *PersonPostgres, action models.PersonAction, oldValues, newValues map[string]any) error {
+	oldJSON, err := marshalValues(oldValues)
+	if err != nil {
+		return err
+	}
+	newJSON, err := marshalValues(newValues)
+	if err != nil {
+		return err
+	}
+
+	query := `insert into service.person_history (person_id, action, actor, version, old_values, new_values)
+				values ($1, $2, $3, $4, $5, $6);`
+	_, err = tx.ExecContext(ctx, query, person.Id, action, actor.FromContext(ctx), person.Version, oldJSON, newJSON)
+	return err
+}
+
+func marshalValues(values map[string]any) ([]byte, error) {
+	if values == nil {
+		return nil, nil
+	}
+	return json.Marshal(values)
+}
+
+func unmarshalValues(data []byte) (map[string]any, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// GetHistory returns the changes of a person, oldest first. The history of
+// purged persons is kept, so only ids that never existed are unknown.
+func (p *PersonPostgresRepository) GetHistory(ctx context.Context, id uint64) ([]models.PersonChange, error) {
+	query := `select * from service.person_history where person_id = $1 order by id;`
+
+	var changesPostgres []PersonChangePostgres
+	err := p.db.SelectContext(ctx, &changesPostgres, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(changesPostgres) == 0 {
+		// Persons created before the history was introduced have none.
+		var exists bool
+		err = p.db.GetContext(ctx, &exists, `select exists (select 1 from service.persons where id = $1);`, id)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, repositoryErrors.ObjectDoesNotExists
+		}
+	}
+
+	changes := make([]models.PersonChange, 0, len(changesPostgres))
+	for _, c := range changesPostgres {
+		change := models.PersonChange{
+			Id:        c.Id,
+			PersonId:  c.PersonId,
+			Action:    c.Action,
+			Actor:     c.Actor,
+			Version:   c.Version,
+			ChangedAt: c.ChangedAt,
+		}
+		if change.OldValues, err = unmarshalValues(c.OldValues); err != nil {
+			return nil, err
+		}
+		if change.NewValues, err = unmarshalValues(c.NewValues); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
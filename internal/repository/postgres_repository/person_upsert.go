@@ -0,0 +1,185 @@
+package postgres_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fio_finder/internal/config"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/repositoryErrors"
+	"fio_finder/pkg/normalize"
+	"github.com/lib/pq"
+	"reflect"
+	"strings"
+)
+
+// naturalKeyIndex guards the natural keys of persons that are not deleted.
+const naturalKeyIndex = "persons_natural_key_idx"
+
+// naturalKeyOf joins the configured parts of a person. Names are compared
+// by their normalized keys, see normalize.Key.
+func (p *PersonPostgresRepository) naturalKeyOf(person *PersonPostgres) *string {
+	if len(p.naturalKey) == 0 {
+		return nil
+	}
+	parts := make([]string, 0, len(p.naturalKey))
+	for _, part := range p.naturalKey {
+		switch part {
+		case config.NaturalKeyName:
+			parts = append(parts, normalize.Key(person.Name))
+		case config.NaturalKeySurname:
+			parts = append(parts, normalize.Key(person.Surname))
+		case config.NaturalKeyPatronymic:
+			parts = append(parts, normalize.Key(person.Patronymic))
+		case config.NaturalKeyExternalId:
+			parts = append(parts, strings.TrimSpace(person.ExternalId))
+		}
+	}
+	key := strings.Join(parts, "|")
+	return &key
+}
+
+// toPersonPostgres prepares a new person for saving: the status defaults to
+// manual and the keys are computed, both also filled in on the person.
+func (p *PersonPostgresRepository) toPersonPostgres(person *models.Person) PersonPostgres {
+	if person.Status == "" {
+		person.Status = models.PersonStatusManual
+	}
+	person.SearchKey = normalize.SearchKey(person.Surname, person.Name, person.Patronymic)
+
+	personPostgres := PersonPostgres{
+		Id:          person.Id,
+		Name:        person.Name,
+		Surname:     person.Surname,
+		Patronymic:  person.Patronymic,
+		Gender:      person.Gender,
+		Age:         person.Age,
+		Nationality: person.Nationality,
+		Status:      person.Status,
+		CountryHint: person.CountryHint,
+		ExternalId:  person.ExternalId,
+		SearchKey:   person.SearchKey,
+	}
+	personPostgres.NaturalKey = p.naturalKeyOf(&personPostgres)
+	return personPostgres
+}
+
+// duplicateError turns a violation of the natural key index into
+// ObjectAlreadyExists.
+func duplicateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == naturalKeyIndex {
+		return repositoryErrors.ObjectAlreadyExists
+	}
+	return err
+}
+
+// Upsert inserts the person, skipping the insert on a natural key conflict.
+// The existing person is then locked and updated like by Update, which also
+// covers a concurrent upsert of the same person. Persons without a natural
+// key are always created.
+func (p *PersonPostgresRepository) Upsert(ctx context.Context, person *models.Person, policy string) (bool, error) {
+	incoming := p.toPersonPostgres(person)
+	if incoming.NaturalKey == nil {
+		return true, p.Create(ctx, person)
+	}
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `insert into service.persons (name, surname, patronymic, age, gender, nationality, status, country_hint,
+					external_id, search_key, natural_key) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				on conflict (natural_key) where deleted_at is null do nothing returning *;`
+	var created PersonPostgres
+	err = tx.GetContext(ctx, &created, query, incoming.Name, incoming.Surname, incoming.Patronymic, incoming.Age,
+		incoming.Gender, incoming.Nationality, incoming.Status, incoming.CountryHint, incoming.ExternalId,
+		incoming.SearchKey, incoming.NaturalKey)
+	if err == nil {
+		if err = p.saveCreated(ctx, tx, &created, person); err != nil {
+			return false, err
+		}
+		person.Id, person.Version = created.Id, created.Version
+		return true, tx.Commit()
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	var existing PersonPostgres
+	err = tx.GetContext(ctx, &existing, `select * from service.persons where natural_key = $1 and deleted_at is null for update;`,
+		incoming.NaturalKey)
+	if err != nil {
+		return false, err
+	}
+
+	updateFields := conflictUpdate(&existing, &incoming, policy)
+	var (
+		enrichments   []models.FieldEnrichment
+		nationalities []models.CountryProbability
+	)
+	for _, e := range person.Enrichments {
+		if _, ok := updateFields[e.Field]; ok {
+			enrichments = append(enrichments, e)
+		}
+	}
+	if _, ok := updateFields[models.PersonValueNationality]; ok {
+		nationalities = person.NationalityCandidates
+	}
+
+	version, err := p.updateLocked(ctx, tx, &existing, updateFields, enrichments, nationalities)
+	if err != nil {
+		return false, err
+	}
+	person.Id, person.Version = existing.Id, version
+	return false, tx.Commit()
+}
+
+// ExistsByNaturalKey tells whether a person that is not deleted shares the
+// natural key of person. Persons without a natural key never do.
+func (p *PersonPostgresRepository) ExistsByNaturalKey(ctx context.Context, person *models.Person) (bool, error) {
+	key := p.naturalKeyOf(&PersonPostgres{
+		Name:       person.Name,
+		Surname:    person.Surname,
+		Patronymic: person.Patronymic,
+		ExternalId: person.ExternalId,
+	})
+	if key == nil {
+		return false, nil
+	}
+
+	var exists bool
+	query := `select exists (select 1 from service.persons where natural_key = $1 and deleted_at is null);`
+	if err := p.db.GetContext(ctx, &exists, query, *key); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// conflictUpdate returns the columns of existing to change when incoming
+// shares its natural key. Columns that would not change are left out, so
+// repeating an upsert changes nothing.
+func conflictUpdate(existing *PersonPostgres, incoming *PersonPostgres, policy string) map[string]any {
+	updateFields := make(map[string]any)
+	if policy == config.ConflictPolicyKeep {
+		return updateFields
+	}
+
+	existingValues := personValues(existing)
+	for column, value := range personValues(incoming) {
+		if policy == config.ConflictPolicyMerge && (column == models.PersonValueStatus || isEmpty(value)) {
+			continue
+		}
+		if !reflect.DeepEqual(existingValues[column], value) {
+			updateFields[column] = value
+		}
+	}
+	return updateFields
+}
+
+// isEmpty tells whether a person value is unset: nil or an empty string.
+func isEmpty(value any) bool {
+	v := reflect.ValueOf(value)
+	return !v.IsValid() || v.IsZero()
+}
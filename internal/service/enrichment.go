@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"fio_finder/internal/models"
+)
+
+type EnrichmentService interface {
+	WarmCache(ctx context.Context, names []string, countryId string) error
+	// RunEnrichmentWorkers enriches persons saved as pending until ctx is done.
+	RunEnrichmentWorkers(ctx context.Context)
+	// RunReEnrichment periodically refreshes stale enrichment until ctx is done.
+	RunReEnrichment(ctx context.Context)
+	ReEnrichmentStatus() models.ReEnrichmentStatus
+	// ProviderHealth reports the circuit breaker state of every provider.
+	ProviderHealth() []models.ProviderHealth
+}
@@ -1,7 +1,11 @@
 package service
 
+//go:generate mockgen -source=kafka.go -destination=mocks/kafka.go
 type KafkaService interface {
 	SendMessages(topic string, message string) error
 	ConsumeMessages(topic string, handler func(message string)) error
+	ConsumeBatches(topic string, handler func(messages []string)) error
+	// Done is closed once consuming stops, so handlers can abandon waits.
+	Done() <-chan struct{}
 	Close()
 }
@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: kafka.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockKafkaService is a mock of KafkaService interface.
+type MockKafkaService struct {
+	ctrl     *gomock.Controller
+	recorder *MockKafkaServiceMockRecorder
+}
+
+// MockKafkaServiceMockRecorder is the mock recorder for MockKafkaService.
+type MockKafkaServiceMockRecorder struct {
+	mock *MockKafkaService
+}
+
+// NewMockKafkaService creates a new mock instance.
+func NewMockKafkaService(ctrl *gomock.Controller) *MockKafkaService {
+	mock := &MockKafkaService{ctrl: ctrl}
+	mock.recorder = &MockKafkaServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKafkaService) EXPECT() *MockKafkaServiceMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockKafkaService) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockKafkaServiceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockKafkaService)(nil).Close))
+}
+
+// ConsumeBatches mocks base method.
+func (m *MockKafkaService) ConsumeBatches(topic string, handler func([]string)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeBatches", topic, handler)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeBatches indicates an expected call of ConsumeBatches.
+func (mr *MockKafkaServiceMockRecorder) ConsumeBatches(topic, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeBatches", reflect.TypeOf((*MockKafkaService)(nil).ConsumeBatches), topic, handler)
+}
+
+// ConsumeMessages mocks base method.
+func (m *MockKafkaService) ConsumeMessages(topic string, handler func(string)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeMessages", topic, handler)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeMessages indicates an expected call of ConsumeMessages.
+func (mr *MockKafkaServiceMockRecorder) ConsumeMessages(topic, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeMessages", reflect.TypeOf((*MockKafkaService)(nil).ConsumeMessages), topic, handler)
+}
+
+// Done mocks base method.
+func (m *MockKafkaService) Done() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Done")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Done indicates an expected call of Done.
+func (mr *MockKafkaServiceMockRecorder) Done() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Done", reflect.TypeOf((*MockKafkaService)(nil).Done))
+}
+
+// SendMessages mocks base method.
+func (m *MockKafkaService) SendMessages(topic, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessages", topic, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMessages indicates an expected call of SendMessages.
+func (mr *MockKafkaServiceMockRecorder) SendMessages(topic, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessages", reflect.TypeOf((*MockKafkaService)(nil).SendMessages), topic, message)
+}
@@ -0,0 +1,286 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: person.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	models "fio_finder/internal/models"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPersonService is a mock of PersonService interface.
+type MockPersonService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPersonServiceMockRecorder
+}
+
+// MockPersonServiceMockRecorder is the mock recorder for MockPersonService.
+type MockPersonServiceMockRecorder struct {
+	mock *MockPersonService
+}
+
+// NewMockPersonService creates a new mock instance.
+func NewMockPersonService(ctrl *gomock.Controller) *MockPersonService {
+	mock := &MockPersonService{ctrl: ctrl}
+	mock.recorder = &MockPersonServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPersonService) EXPECT() *MockPersonServiceMockRecorder {
+	return m.recorder
+}
+
+// BatchEnrich mocks base method.
+func (m *MockPersonService) BatchEnrich(ctx context.Context, persons []models.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchEnrich", ctx, persons)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchEnrich indicates an expected call of BatchEnrich.
+func (mr *MockPersonServiceMockRecorder) BatchEnrich(ctx, persons interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchEnrich", reflect.TypeOf((*MockPersonService)(nil).BatchEnrich), ctx, persons)
+}
+
+// Create mocks base method.
+func (m *MockPersonService) Create(ctx context.Context, person *models.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, person)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPersonServiceMockRecorder) Create(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPersonService)(nil).Create), ctx, person)
+}
+
+// CreateMany mocks base method.
+func (m *MockPersonService) CreateMany(ctx context.Context, persons []models.Person) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, persons)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockPersonServiceMockRecorder) CreateMany(ctx, persons interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockPersonService)(nil).CreateMany), ctx, persons)
+}
+
+// CreatePending mocks base method.
+func (m *MockPersonService) CreatePending(ctx context.Context, person *models.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePending", ctx, person)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePending indicates an expected call of CreatePending.
+func (mr *MockPersonServiceMockRecorder) CreatePending(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePending", reflect.TypeOf((*MockPersonService)(nil).CreatePending), ctx, person)
+}
+
+// CreateWithEnrichment mocks base method.
+func (m *MockPersonService) CreateWithEnrichment(ctx context.Context, person *models.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithEnrichment", ctx, person)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWithEnrichment indicates an expected call of CreateWithEnrichment.
+func (mr *MockPersonServiceMockRecorder) CreateWithEnrichment(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithEnrichment", reflect.TypeOf((*MockPersonService)(nil).CreateWithEnrichment), ctx, person)
+}
+
+// Delete mocks base method.
+func (m *MockPersonService) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPersonServiceMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPersonService)(nil).Delete), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockPersonService) Get(ctx context.Context, id uint64) (*models.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPersonServiceMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPersonService)(nil).Get), ctx, id)
+}
+
+// GetAsOf mocks base method.
+func (m *MockPersonService) GetAsOf(ctx context.Context, id uint64, asOf time.Time) (*models.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAsOf", ctx, id, asOf)
+	ret0, _ := ret[0].(*models.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAsOf indicates an expected call of GetAsOf.
+func (mr *MockPersonServiceMockRecorder) GetAsOf(ctx, id, asOf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAsOf", reflect.TypeOf((*MockPersonService)(nil).GetAsOf), ctx, id, asOf)
+}
+
+// GetHistory mocks base method.
+func (m *MockPersonService) GetHistory(ctx context.Context, id uint64) ([]models.PersonChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, id)
+	ret0, _ := ret[0].([]models.PersonChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockPersonServiceMockRecorder) GetHistory(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockPersonService)(nil).GetHistory), ctx, id)
+}
+
+// GetList mocks base method.
+func (m *MockPersonService) GetList(ctx context.Context, filter models.PersonFilter) (*models.PersonList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetList", ctx, filter)
+	ret0, _ := ret[0].(*models.PersonList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetList indicates an expected call of GetList.
+func (mr *MockPersonServiceMockRecorder) GetList(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockPersonService)(nil).GetList), ctx, filter)
+}
+
+// IsKnown mocks base method.
+func (m *MockPersonService) IsKnown(ctx context.Context, person *models.Person) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsKnown", ctx, person)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsKnown indicates an expected call of IsKnown.
+func (mr *MockPersonServiceMockRecorder) IsKnown(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsKnown", reflect.TypeOf((*MockPersonService)(nil).IsKnown), ctx, person)
+}
+
+// Purge mocks base method.
+func (m *MockPersonService) Purge(ctx context.Context) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockPersonServiceMockRecorder) Purge(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockPersonService)(nil).Purge), ctx)
+}
+
+// Restore mocks base method.
+func (m *MockPersonService) Restore(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockPersonServiceMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPersonService)(nil).Restore), ctx, id)
+}
+
+// Search mocks base method.
+func (m *MockPersonService) Search(ctx context.Context, query string, limit int) ([]models.PersonMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]models.PersonMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPersonServiceMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPersonService)(nil).Search), ctx, query, limit)
+}
+
+// Update mocks base method.
+func (m *MockPersonService) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, expectedVersion *uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, fieldsToUpdate, expectedVersion)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockPersonServiceMockRecorder) Update(ctx, id, fieldsToUpdate, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPersonService)(nil).Update), ctx, id, fieldsToUpdate, expectedVersion)
+}
+
+// Upsert mocks base method.
+func (m *MockPersonService) Upsert(ctx context.Context, person *models.Person) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, person)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockPersonServiceMockRecorder) Upsert(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockPersonService)(nil).Upsert), ctx, person)
+}
+
+// UpsertWithEnrichment mocks base method.
+func (m *MockPersonService) UpsertWithEnrichment(ctx context.Context, person *models.Person) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertWithEnrichment", ctx, person)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertWithEnrichment indicates an expected call of UpsertWithEnrichment.
+func (mr *MockPersonServiceMockRecorder) UpsertWithEnrichment(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertWithEnrichment", reflect.TypeOf((*MockPersonService)(nil).UpsertWithEnrichment), ctx, person)
+}
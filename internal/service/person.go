@@ -3,18 +3,53 @@ package service
 import (
 	"context"
 	"fio_finder/internal/models"
+	"time"
 )
 
+//go:generate mockgen -source=person.go -destination=mocks/person.go
 type PersonService interface {
 	Create(ctx context.Context, person *models.Person) error
 	CreateWithEnrichment(ctx context.Context, person *models.Person) error
+	// CreatePending saves a person right away and leaves enrichment to the
+	// background workers.
+	CreatePending(ctx context.Context, person *models.Person) error
+	BatchEnrich(ctx context.Context, persons []models.Person) error
+	// CreateMany saves up to models.MaxPersonBatchSize persons at once, all
+	// or none, and returns their ids in the order given.
+	CreateMany(ctx context.Context, persons []models.Person) ([]uint64, error)
+	// Upsert creates a person unless one with the same natural key exists,
+	// resolving the conflict with the configured policy. It reports whether
+	// the person was created.
+	Upsert(ctx context.Context, person *models.Person) (bool, error)
+	// UpsertWithEnrichment is Upsert for a person that still has to be
+	// enriched. A known person is only enriched under the overwrite policy,
+	// as keep and merge leave its enriched fields alone anyway.
+	UpsertWithEnrichment(ctx context.Context, person *models.Person) (bool, error)
+	// IsKnown tells whether UpsertWithEnrichment would resolve the person
+	// into a stored one without enriching it.
+	IsKnown(ctx context.Context, person *models.Person) (bool, error)
 	Delete(ctx context.Context, id uint64) error
-	Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate) error
+	Restore(ctx context.Context, id uint64) error
+	// Purge permanently removes persons deleted longer than the retention
+	// period ago and returns their number.
+	Purge(ctx context.Context) (uint64, error)
+	// Update applies the change only if the person is still at
+	// expectedVersion, unless it is nil, and returns the new version.
+	Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, expectedVersion *uint64) (uint64, error)
 	Get(ctx context.Context, id uint64) (*models.Person, error)
-	GetList(ctx context.Context) ([]models.Person, error)
+	// GetHistory returns who changed a person and how, oldest change first.
+	GetHistory(ctx context.Context, id uint64) ([]models.PersonChange, error)
+	// GetAsOf rebuilds a person as it was at the given moment from its
+	// history, without enrichment records and nationality candidates.
+	GetAsOf(ctx context.Context, id uint64, asOf time.Time) (*models.Person, error)
+	GetList(ctx context.Context, filter models.PersonFilter) (*models.PersonList, error)
+	// Search finds persons by partial or misspelled names, best matches
+	// first.
+	Search(ctx context.Context, query string, limit int) ([]models.PersonMatch, error)
 }
 
 type Services struct {
-	Person PersonService
-	Kafka  KafkaService
+	Person     PersonService
+	Enrichment EnrichmentService
+	Kafka      KafkaService
 }
@@ -0,0 +1,588 @@
+package serviceImpl
+
+import (
+	"context"
+	"errors"
+	"fio_finder/internal/config"
+	"fio_finder/internal/enrichment"
+	"fio_finder/internal/enrichment/gender_rules"
+	"fio_finder/internal/models"
+	"fio_finder/internal/repository"
+	"fio_finder/internal/service"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"fio_finder/pkg/errors/repositoryErrors"
+	"fio_finder/pkg/logger"
+	"fio_finder/pkg/normalize"
+	"strings"
+	"sync"
+	"time"
+)
+
+type enrichmentResult struct {
+	age         *models.AgeEnrichment
+	gender      *models.GenderEnrichment
+	nationality *models.NationalityEnrichment
+
+	ageErr, genderErr, nationalityErr error
+}
+
+func (r *enrichmentResult) err() error {
+	return errors.Join(r.ageErr, r.genderErr, r.nationalityErr)
+}
+
+// enrich queries all providers concurrently. Each provider applies its own
+// deadline, so the returned error lists every provider that failed. Fields
+// the provider knows nothing about or asked to skip are left nil in the
+// result and stay unknown.
+func enrich(ctx context.Context, enricher enrichment.Enricher, genderRules config.GenderRulesConfig, person *models.Person) (*enrichmentResult, error) {
+	res, err := enrichPerson(ctx, enricher, genderRules, person)
+	if err := withoutUnenriched(err); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// enrichPerson is like enrich but hands back whatever succeeded together
+// with the joined error. Gender is taken from the rule-based inference, the
+// gender provider or both according to genderRules. A gender provider
+// failure does not count when the rules inferred the gender.
+func enrichPerson(ctx context.Context, enricher enrichment.Enricher, genderRules config.GenderRulesConfig, person *models.Person) (*enrichmentResult, error) {
+	return enrichPersonFields(ctx, enricher, genderRules, person,
+		models.EnrichedFieldAge, models.EnrichedFieldGender, models.EnrichedFieldNationality)
+}
+
+// enrichPersonFields is enrichPerson limited to the given fields.
+func enrichPersonFields(ctx context.Context, enricher enrichment.Enricher, genderRules config.GenderRulesConfig, person *models.Person, fields ...string) (*enrichmentResult, error) {
+	var ruled *models.GenderEnrichment
+	provided := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field == models.EnrichedFieldGender {
+			ruled = inferGender(genderRules, person)
+			if !needsGenderProvider(genderRules, ruled) {
+				continue
+			}
+		}
+		provided = append(provided, field)
+	}
+	res, _ := enrichFields(ctx, enricher, enrichmentName(person.Name), person.CountryHint, provided...)
+
+	res.gender = chooseGender(genderRules, ruled, res.gender)
+	if ruled != nil {
+		res.genderErr = nil
+	}
+	return res, res.err()
+}
+
+// enrichmentName is the spelling of a first name sent to the providers,
+// which only know Latin spellings.
+func enrichmentName(name string) string {
+	return normalize.Transliterate(normalize.Clean(name), normalize.ICAO)
+}
+
+// inferGender returns nil when the rules are disabled or the patronymic and
+// surname have no known ending.
+func inferGender(genderRules config.GenderRulesConfig, person *models.Person) *models.GenderEnrichment {
+	if !genderRules.Enabled {
+		return nil
+	}
+	return gender_rules.Infer(person.Patronymic, person.Surname)
+}
+
+func needsGenderProvider(genderRules config.GenderRulesConfig, ruled *models.GenderEnrichment) bool {
+	return ruled == nil || genderRules.Precedence != config.GenderPrecedenceRules
+}
+
+// chooseGender settles between the rule-based and the provider result when
+// both are known.
+func chooseGender(genderRules config.GenderRulesConfig, ruled *models.GenderEnrichment, provided *models.GenderEnrichment) *models.GenderEnrichment {
+	switch {
+	case ruled == nil:
+		return provided
+	case provided == nil:
+		return ruled
+	}
+
+	switch genderRules.Precedence {
+	case config.GenderPrecedenceRules:
+		return ruled
+	case config.GenderPrecedenceApi:
+		return provided
+	default:
+		if provided.Probability > ruled.Probability {
+			return provided
+		}
+		return ruled
+	}
+}
+
+// withoutUnenriched drops empty results and failures marked with
+// FieldSkipped from a joined error, since such fields are meant to be saved
+// as unknown.
+func withoutUnenriched(err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		if isUnenriched(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		if !isUnenriched(e) {
+			errs = append(errs, e)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isUnenriched(err error) bool {
+	return errors.Is(err, enrichmentErrors.EmptyResult) || errors.Is(err, enrichmentErrors.FieldSkipped)
+}
+
+// enrichFields queries only the providers behind the given fields. Unlike
+// enrich it hands back whatever succeeded together with the joined error.
+func enrichFields(ctx context.Context, enricher enrichment.Enricher, name string, countryId string, fields ...string) (*enrichmentResult, error) {
+	var (
+		wg  sync.WaitGroup
+		res enrichmentResult
+	)
+
+	for _, field := range fields {
+		switch field {
+		case models.EnrichedFieldAge:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res.age, res.ageErr = enricher.GetAge(ctx, name, countryId)
+			}()
+		case models.EnrichedFieldGender:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res.gender, res.genderErr = enricher.GetGender(ctx, name, countryId)
+			}()
+		case models.EnrichedFieldNationality:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res.nationality, res.nationalityErr = enricher.GetNationality(ctx, name)
+			}()
+		}
+	}
+	wg.Wait()
+
+	return &res, res.err()
+}
+
+type batchEnrichmentResult struct {
+	ages          map[string]*models.AgeEnrichment
+	genders       map[string]*models.GenderEnrichment
+	nationalities map[string]*models.NationalityEnrichment
+}
+
+// enrichBatch is the multi-name counterpart of enrich. Genders are only
+// requested for genderNames.
+func enrichBatch(ctx context.Context, enricher enrichment.Enricher, names []string, genderNames []string, countryId string) (*batchEnrichmentResult, error) {
+	var (
+		wg                                sync.WaitGroup
+		res                               batchEnrichmentResult
+		ageErr, genderErr, nationalityErr error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		res.ages, ageErr = enricher.GetAges(ctx, names, countryId)
+	}()
+	if len(genderNames) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res.genders, genderErr = enricher.GetGenders(ctx, genderNames, countryId)
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		res.nationalities, nationalityErr = enricher.GetNationalities(ctx, names)
+	}()
+	wg.Wait()
+
+	if err := withoutUnenriched(errors.Join(ageErr, genderErr, nationalityErr)); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// normalizeCountryHint upper-cases the hint and checks that it looks like an
+// ISO 3166-1 alpha-2 code.
+func normalizeCountryHint(countryId string) (string, error) {
+	countryId = strings.ToUpper(strings.TrimSpace(countryId))
+	if countryId == "" {
+		return "", nil
+	}
+	if len(countryId) != 2 || countryId[0] < 'A' || countryId[0] > 'Z' || countryId[1] < 'A' || countryId[1] > 'Z' {
+		return "", enrichmentErrors.InvalidCountryHint
+	}
+	return countryId, nil
+}
+
+func applyAge(person *models.Person, age *models.AgeEnrichment, at time.Time) {
+	value := age.Age
+	person.Age = &value
+	person.Enrichments = append(person.Enrichments, models.FieldEnrichment{
+		Field:       models.EnrichedFieldAge,
+		Provider:    age.Provider,
+		Count:       age.Count,
+		CountryHint: person.CountryHint,
+		EnrichedAt:  at,
+	})
+}
+
+func applyGender(person *models.Person, gender *models.GenderEnrichment, at time.Time) {
+	probability := gender.Probability
+	value := gender.Gender
+	person.Gender = &value
+	person.Enrichments = append(person.Enrichments, models.FieldEnrichment{
+		Field:       models.EnrichedFieldGender,
+		Provider:    gender.Provider,
+		Probability: &probability,
+		Count:       gender.Count,
+		CountryHint: person.CountryHint,
+		EnrichedAt:  at,
+	})
+}
+
+func applyNationality(person *models.Person, nationality *models.NationalityEnrichment, at time.Time) {
+	top := nationality.Countries[0]
+	person.Nationality = &top.CountryId
+	person.NationalityCandidates = nationality.Countries
+	person.Enrichments = append(person.Enrichments, models.FieldEnrichment{
+		Field:       models.EnrichedFieldNationality,
+		Provider:    nationality.Provider,
+		Probability: &top.Probability,
+		Count:       nationality.Count,
+		EnrichedAt:  at,
+	})
+}
+
+type enrichmentServiceImplementation struct {
+	personRepository repository.PersonRepository
+	enricher         enrichment.Enricher
+	health           enrichment.HealthReporter
+	logger           *logger.Logger
+	reEnrichConfig   config.ReEnrichmentConfig
+	asyncConfig      config.AsyncEnrichmentConfig
+	genderRules      config.GenderRulesConfig
+
+	statusMu sync.Mutex
+	status   models.ReEnrichmentStatus
+}
+
+// NewEnrichmentServiceImplementation creates the service. health may be nil
+// when the enrichment backend does not track provider availability.
+func NewEnrichmentServiceImplementation(personRepository repository.PersonRepository, enricher enrichment.Enricher,
+	health enrichment.HealthReporter, logger *logger.Logger, cfg config.EnrichmentConfig) service.EnrichmentService {
+	return &enrichmentServiceImplementation{
+		personRepository: personRepository,
+		enricher:         enricher,
+		health:           health,
+		logger:           logger,
+		reEnrichConfig:   cfg.ReEnrich,
+		asyncConfig:      cfg.Async,
+		genderRules:      cfg.GenderRules,
+	}
+}
+
+func (e *enrichmentServiceImplementation) WarmCache(ctx context.Context, names []string, countryId string) error {
+	countryId, err := normalizeCountryHint(countryId)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		name = enrichmentName(name)
+		key := strings.ToLower(name)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, name)
+	}
+	fields := map[string]interface{}{"names": len(unique), "country": countryId}
+
+	if _, err := enrichBatch(ctx, e.enricher, unique, unique, countryId); err != nil {
+		e.logger.WithFields(fields).Error("enrichment cache warm failed: " + err.Error())
+		return err
+	}
+	e.logger.WithFields(fields).Info("enrichment cache warm completed")
+	return nil
+}
+
+func (e *enrichmentServiceImplementation) ProviderHealth() []models.ProviderHealth {
+	if e.health == nil {
+		return []models.ProviderHealth{}
+	}
+	return e.health.Health()
+}
+
+func (e *enrichmentServiceImplementation) RunEnrichmentWorkers(ctx context.Context) {
+	if !e.asyncConfig.Enabled || e.asyncConfig.Workers <= 0 {
+		return
+	}
+
+	jobs := make(chan models.Person)
+	var wg sync.WaitGroup
+	for i := 0; i < e.asyncConfig.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for person := range jobs {
+				e.enrichPending(ctx, &person)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(e.asyncConfig.PollInterval)
+	defer ticker.Stop()
+	for {
+		persons, err := e.personRepository.ClaimPending(ctx, e.asyncConfig.Lease, e.asyncConfig.Workers)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Error("claim pending persons failed: " + err.Error())
+		}
+
+		for _, person := range persons {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- person:
+			}
+		}
+
+		if len(persons) > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enrichPending enriches a person saved as pending and moves it to its final
+// status. When a provider quota is exhausted or the person is edited
+// meanwhile it stays pending and is claimed again once its lease expires.
+// Fields edited by a user are kept as is and count as known.
+func (e *enrichmentServiceImplementation) enrichPending(ctx context.Context, person *models.Person) {
+	fields := map[string]interface{}{"id": person.Id}
+
+	manual := make(map[string]bool)
+	for _, enrichment := range person.Enrichments {
+		if enrichment.Provider == models.ManualProvider {
+			manual[enrichment.Field] = true
+		}
+	}
+	var unknown []string
+	for _, field := range []string{models.EnrichedFieldAge, models.EnrichedFieldGender, models.EnrichedFieldNationality} {
+		if !manual[field] {
+			unknown = append(unknown, field)
+		}
+	}
+
+	res, err := enrichPersonFields(ctx, e.enricher, e.genderRules, person, unknown...)
+	if errors.Is(err, enrichmentErrors.QuotaExceeded) {
+		e.logger.WithFields(fields).Warn("person enrichment postponed: " + err.Error())
+		return
+	}
+	err = withoutUnenriched(err)
+
+	now := time.Now()
+	updated := &models.Person{CountryHint: person.CountryHint}
+	fieldsToUpdate := make(models.PersonFieldsToUpdate)
+	if res.age != nil {
+		applyAge(updated, res.age, now)
+		fieldsToUpdate[models.PersonFieldAge] = *updated.Age
+	}
+	if res.gender != nil {
+		applyGender(updated, res.gender, now)
+		fieldsToUpdate[models.PersonFieldGender] = *updated.Gender
+	}
+	if res.nationality != nil {
+		applyNationality(updated, res.nationality, now)
+		fieldsToUpdate[models.PersonFieldNationality] = *updated.Nationality
+	}
+
+	// Unknown and skipped fields are saved without an error, yet the person
+	// only counts as enriched once every field is known.
+	switch {
+	case (res.age != nil || manual[models.EnrichedFieldAge]) && (res.gender != nil || manual[models.EnrichedFieldGender]) &&
+		(res.nationality != nil || manual[models.EnrichedFieldNationality]):
+		fieldsToUpdate[models.PersonFieldStatus] = models.PersonStatusEnriched
+	case len(updated.Enrichments) > 0 || len(manual) > 0:
+		fieldsToUpdate[models.PersonFieldStatus] = models.PersonStatusPartiallyEnriched
+	default:
+		fieldsToUpdate[models.PersonFieldStatus] = models.PersonStatusEnrichmentFailed
+	}
+	if err != nil {
+		e.logger.WithFields(fields).Error("person enrichment failed: " + err.Error())
+	}
+
+	err = e.personRepository.UpdateEnrichment(ctx, person.Id, fieldsToUpdate, updated.Enrichments, updated.NationalityCandidates, &person.Version)
+	if errors.Is(err, repositoryErrors.VersionConflict) {
+		e.logger.WithFields(fields).Info("person changed meanwhile, enrichment postponed")
+		return
+	} else if err != nil {
+		e.logger.WithFields(fields).Error("person enrichment update failed: " + err.Error())
+		return
+	}
+	e.logger.WithFields(fields).Info("person enrichment completed: " + string(fieldsToUpdate[models.PersonFieldStatus].(models.PersonStatus)))
+}
+
+func (e *enrichmentServiceImplementation) RunReEnrichment(ctx context.Context) {
+	if !e.reEnrichConfig.Enabled || e.reEnrichConfig.Rate <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(e.reEnrichConfig.Interval)
+	defer ticker.Stop()
+	for {
+		e.reEnrichStale(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *enrichmentServiceImplementation) ReEnrichmentStatus() models.ReEnrichmentStatus {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	return e.status
+}
+
+// reEnrichStale walks over all stale persons once, processing at most
+// Rate persons per minute.
+func (e *enrichmentServiceImplementation) reEnrichStale(ctx context.Context) {
+	e.updateStatus(func(status *models.ReEnrichmentStatus) {
+		*status = models.ReEnrichmentStatus{Running: true, StartedAt: time.Now()}
+	})
+	e.logger.Info("re-enrichment started")
+	defer func() {
+		status := e.updateStatus(func(status *models.ReEnrichmentStatus) {
+			status.Running = false
+			status.FinishedAt = time.Now()
+		})
+		e.logger.WithFields(map[string]interface{}{
+			"processed": status.Processed, "enriched": status.Enriched, "failed": status.Failed,
+		}).Info("re-enrichment finished")
+	}()
+
+	limiter := time.NewTicker(time.Minute / time.Duration(e.reEnrichConfig.Rate))
+	defer limiter.Stop()
+
+	enrichedBefore := time.Now().Add(-e.reEnrichConfig.MaxAge)
+	var afterId uint64
+	for {
+		persons, err := e.personRepository.GetStale(ctx, enrichedBefore, afterId, e.reEnrichConfig.BatchSize)
+		if err != nil {
+			e.logger.Error("re-enrichment get stale persons failed: " + err.Error())
+			return
+		}
+		if len(persons) == 0 {
+			return
+		}
+
+		for i := range persons {
+			select {
+			case <-ctx.Done():
+				return
+			case <-limiter.C:
+			}
+			afterId = persons[i].Id
+
+			err := e.reEnrichPerson(ctx, &persons[i], enrichedBefore)
+			status := e.updateStatus(func(status *models.ReEnrichmentStatus) {
+				status.Processed++
+				if err != nil {
+					status.Failed++
+				} else {
+					status.Enriched++
+				}
+			})
+			if err != nil {
+				e.logger.WithField("id", persons[i].Id).Error("person re-enrichment failed: " + err.Error())
+			}
+			if status.Processed%100 == 0 {
+				e.logger.WithField("processed", status.Processed).Info("re-enrichment in progress")
+			}
+		}
+	}
+}
+
+// reEnrichPerson refreshes fields that were never enriched or were enriched by
+// a provider before enrichedBefore. Fields edited by a user are kept as is,
+// also when the edit happens while the providers are asked.
+func (e *enrichmentServiceImplementation) reEnrichPerson(ctx context.Context, person *models.Person, enrichedBefore time.Time) error {
+	existing := make(map[string]models.FieldEnrichment, len(person.Enrichments))
+	for _, enrichment := range person.Enrichments {
+		existing[enrichment.Field] = enrichment
+	}
+
+	var stale []string
+	for _, field := range []string{models.EnrichedFieldAge, models.EnrichedFieldGender, models.EnrichedFieldNationality} {
+		enrichment, ok := existing[field]
+		if !ok || (enrichment.Provider != models.ManualProvider && enrichment.EnrichedAt.Before(enrichedBefore)) {
+			stale = append(stale, field)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	res, enrichErr := enrichPersonFields(ctx, e.enricher, e.genderRules, person, stale...)
+
+	now := time.Now()
+	updated := &models.Person{CountryHint: person.CountryHint}
+	fieldsToUpdate := make(models.PersonFieldsToUpdate)
+	if res.age != nil {
+		applyAge(updated, res.age, now)
+		fieldsToUpdate[models.PersonFieldAge] = *updated.Age
+	}
+	if res.gender != nil {
+		applyGender(updated, res.gender, now)
+		fieldsToUpdate[models.PersonFieldGender] = *updated.Gender
+	}
+	if res.nationality != nil {
+		applyNationality(updated, res.nationality, now)
+		fieldsToUpdate[models.PersonFieldNationality] = *updated.Nationality
+	}
+
+	// A person edited since it was read is left for the next run rather than
+	// having the edit overwritten.
+	err := e.personRepository.UpdateEnrichment(ctx, person.Id, fieldsToUpdate, updated.Enrichments, updated.NationalityCandidates, &person.Version)
+	if errors.Is(err, repositoryErrors.VersionConflict) {
+		e.logger.WithField("id", person.Id).Info("person changed meanwhile, re-enrichment skipped")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return enrichErr
+}
+
+func (e *enrichmentServiceImplementation) updateStatus(update func(status *models.ReEnrichmentStatus)) models.ReEnrichmentStatus {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	update(&e.status)
+	return e.status
+}
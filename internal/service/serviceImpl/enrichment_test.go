@@ -0,0 +1,297 @@
+package serviceImpl
+
+import (
+	"context"
+	"fio_finder/internal/config"
+	"fio_finder/internal/models"
+	"fio_finder/pkg/errors/enrichmentErrors"
+	"fio_finder/pkg/errors/repositoryErrors"
+	"fio_finder/pkg/logger"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createEnrichmentService(fields *personServiceFields) *enrichmentServiceImplementation {
+	return NewEnrichmentServiceImplementation(fields.personRepositoryMock, fields.enricherMock, nil,
+		logger.New("/dev/null", ""), config.EnrichmentConfig{}).(*enrichmentServiceImplementation)
+}
+
+var reEnrichBefore = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var testReEnrichPersonSuccess = []struct {
+	TestName    string
+	GenderRules config.GenderRulesConfig
+	InputData   struct {
+		person *models.Person
+	}
+	Prepare     func(fields *personServiceFields)
+	CheckOutput func(t *testing.T, err error)
+}{
+	{
+		TestName: "stale and missing fields are refreshed, manual fields are kept",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 3, Name: "Vasya", Surname: "Pupkin", CountryHint: "RU", Enrichments: []models.FieldEnrichment{
+			{Field: models.EnrichedFieldAge, Provider: "agify", CountryHint: "KZ", EnrichedAt: reEnrichBefore.Add(-time.Hour)},
+			{Field: models.EnrichedFieldGender, Provider: models.ManualProvider, EnrichedAt: reEnrichBefore.Add(-time.Hour)},
+		}}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "RU").Return(&models.AgeEnrichment{Age: 30, Count: 10, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}}, Count: 5, Provider: "nationalize",
+			}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldAge:         uint64(30),
+				models.PersonFieldNationality: "RU",
+			}, gomock.Len(2), []models.CountryProbability{{CountryId: "RU", Probability: 0.8}}, ptr(uint64(3))).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, err error) {
+			require.NoError(t, err)
+		},
+	},
+	{
+		TestName:    "stale gender follows the gender rules",
+		GenderRules: config.GenderRulesConfig{Enabled: true, Precedence: config.GenderPrecedenceRules},
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 3, Name: "Vasya", Surname: "Pupkin", Patronymic: "Ivanovich", Enrichments: []models.FieldEnrichment{
+			{Field: models.EnrichedFieldAge, Provider: "agify", EnrichedAt: reEnrichBefore.Add(time.Hour)},
+			{Field: models.EnrichedFieldGender, Provider: "genderize", EnrichedAt: reEnrichBefore.Add(-time.Hour)},
+			{Field: models.EnrichedFieldNationality, Provider: "nationalize", EnrichedAt: reEnrichBefore.Add(time.Hour)},
+		}}},
+		Prepare: func(fields *personServiceFields) {
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldGender: models.MaleUserGender,
+			}, gomock.Len(1), gomock.Nil(), ptr(uint64(3))).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, err error) {
+			require.NoError(t, err)
+		},
+	},
+	{
+		TestName: "person edited meanwhile is skipped",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 3, Name: "Vasya", Surname: "Pupkin", Enrichments: []models.FieldEnrichment{
+			{Field: models.EnrichedFieldAge, Provider: "agify", EnrichedAt: reEnrichBefore.Add(-time.Hour)},
+			{Field: models.EnrichedFieldGender, Provider: "genderize", EnrichedAt: reEnrichBefore.Add(time.Hour)},
+			{Field: models.EnrichedFieldNationality, Provider: "nationalize", EnrichedAt: reEnrichBefore.Add(time.Hour)},
+		}}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(&models.AgeEnrichment{Age: 30, Provider: "agify"}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldAge: uint64(30),
+			}, gomock.Len(1), gomock.Nil(), ptr(uint64(3))).Return(&repositoryErrors.VersionConflictError{Expected: 3, Actual: 4})
+		},
+		CheckOutput: func(t *testing.T, err error) {
+			require.NoError(t, err)
+		},
+	},
+	{
+		TestName: "fresh and manual fields are skipped",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 3, Name: "Vasya", Surname: "Pupkin", Enrichments: []models.FieldEnrichment{
+			{Field: models.EnrichedFieldAge, Provider: "agify", EnrichedAt: reEnrichBefore.Add(time.Hour)},
+			{Field: models.EnrichedFieldGender, Provider: models.ManualProvider, EnrichedAt: reEnrichBefore.Add(-time.Hour)},
+			{Field: models.EnrichedFieldNationality, Provider: models.ManualProvider, EnrichedAt: reEnrichBefore.Add(-time.Hour)},
+		}}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.NoError(t, err)
+		},
+	},
+}
+
+func TestEnrichmentServiceImplementation_reEnrichPerson(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range testReEnrichPersonSuccess {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			enrichmentService := createEnrichmentService(fields)
+			enrichmentService.genderRules = tt.GenderRules
+
+			err := enrichmentService.reEnrichPerson(context.Background(), tt.InputData.person, reEnrichBefore)
+
+			tt.CheckOutput(t, err)
+		})
+	}
+}
+
+var testEnrichPending = []struct {
+	TestName  string
+	InputData struct {
+		person *models.Person
+	}
+	Prepare func(fields *personServiceFields)
+}{
+	{
+		TestName: "all fields known",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(&models.AgeEnrichment{Age: 30}, nil)
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+			}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldAge:         uint64(30),
+				models.PersonFieldGender:      models.MaleUserGender,
+				models.PersonFieldNationality: "RU",
+				models.PersonFieldStatus:      models.PersonStatusEnriched,
+			}, gomock.Len(3), gomock.Len(1), ptr(uint64(2))).Return(nil)
+		},
+	},
+	{
+		TestName: "skipped field leaves person partially enriched",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(nil, &enrichmentErrors.ProviderError{
+				Provider: "agify", Err: fmt.Errorf("%w, %w", enrichmentErrors.CircuitOpen, enrichmentErrors.FieldSkipped),
+			})
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+			}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldGender:      models.MaleUserGender,
+				models.PersonFieldNationality: "RU",
+				models.PersonFieldStatus:      models.PersonStatusPartiallyEnriched,
+			}, gomock.Len(2), gomock.Len(1), ptr(uint64(2))).Return(nil)
+		},
+	},
+	{
+		TestName: "unknown results leave person partially enriched",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya", CountryHint: "RU"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "RU").Return(&models.AgeEnrichment{Age: 30}, nil)
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "RU").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "genderize", Err: enrichmentErrors.EmptyResult})
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+			}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldAge:         uint64(30),
+				models.PersonFieldNationality: "RU",
+				models.PersonFieldStatus:      models.PersonStatusPartiallyEnriched,
+			}, gomock.Len(2), gomock.Len(1), ptr(uint64(2))).Return(nil)
+		},
+	},
+	{
+		TestName: "some providers failed",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(&models.AgeEnrichment{Age: 30}, nil)
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "genderize", Err: enrichmentErrors.UnexpectedStatus})
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "nationalize", Err: enrichmentErrors.UnexpectedStatus})
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldAge:    uint64(30),
+				models.PersonFieldStatus: models.PersonStatusPartiallyEnriched,
+			}, gomock.Len(1), gomock.Nil(), ptr(uint64(2))).Return(nil)
+		},
+	},
+	{
+		TestName: "all providers failed",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {
+			providerErr := &enrichmentErrors.ProviderError{Provider: "agify", Err: enrichmentErrors.UnexpectedStatus}
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(nil, providerErr)
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(nil, providerErr)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(nil, providerErr)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldStatus: models.PersonStatusEnrichmentFailed,
+			}, gomock.Len(0), gomock.Nil(), ptr(uint64(2))).Return(nil)
+		},
+	},
+	{
+		TestName: "manually edited field is kept",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya", Enrichments: []models.FieldEnrichment{
+			{Field: models.EnrichedFieldAge, Provider: models.ManualProvider},
+		}}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+			}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), models.PersonFieldsToUpdate{
+				models.PersonFieldGender:      models.MaleUserGender,
+				models.PersonFieldNationality: "RU",
+				models.PersonFieldStatus:      models.PersonStatusEnriched,
+			}, gomock.Len(2), gomock.Len(1), ptr(uint64(2))).Return(nil)
+		},
+	},
+	{
+		TestName: "person edited meanwhile stays pending",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(&models.AgeEnrichment{Age: 30}, nil)
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+			}, nil)
+			fields.personRepositoryMock.EXPECT().UpdateEnrichment(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), gomock.Any(),
+				ptr(uint64(2))).Return(&repositoryErrors.VersionConflictError{Expected: 2, Actual: 3})
+		},
+	},
+	{
+		TestName: "quota exhausted keeps person pending",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Id: 1, Version: 2, Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {
+			quotaErr := &enrichmentErrors.ProviderError{Provider: "agify", Err: &enrichmentErrors.QuotaError{Provider: "agify"}}
+			fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(nil, quotaErr)
+			fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil)
+			fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(nil, quotaErr)
+		},
+	},
+}
+
+func TestEnrichmentServiceImplementation_enrichPending(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range testEnrichPending {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			enrichmentService := createEnrichmentService(fields)
+
+			enrichmentService.enrichPending(context.Background(), tt.InputData.person)
+		})
+	}
+}
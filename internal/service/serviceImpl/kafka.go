@@ -5,20 +5,25 @@ import (
 	"fio_finder/internal/service"
 	"fio_finder/pkg/kafka"
 	"log"
+	"time"
 )
 
 type KafkaServiceImplementation struct {
 	producer         *kafka.Producer
 	consumer         *kafka.Consumer
 	personRepository repository.PersonRepository
+	batchSize        int
+	batchWait        time.Duration
 	ResponseCh       chan []byte
 }
 
-func NewKafkaSerivce(producer *kafka.Producer, consumer *kafka.Consumer, personRepository repository.PersonRepository) service.KafkaService {
+func NewKafkaSerivce(producer *kafka.Producer, consumer *kafka.Consumer, personRepository repository.PersonRepository, batchSize int, batchWait time.Duration) service.KafkaService {
 	return &KafkaServiceImplementation{
 		producer:         producer,
 		consumer:         consumer,
 		personRepository: personRepository,
+		batchSize:        batchSize,
+		batchWait:        batchWait,
 		ResponseCh:       make(chan []byte),
 	}
 }
@@ -37,6 +42,19 @@ func (s *KafkaServiceImplementation) ConsumeMessages(topic string, handler func(
 	return s.consumer.ConsumeMessages(topic, handler)
 }
 
+func (s *KafkaServiceImplementation) ConsumeBatches(topic string, handler func(messages []string)) error {
+	if s.batchSize <= 1 {
+		return s.consumer.ConsumeMessages(topic, func(message string) {
+			handler([]string{message})
+		})
+	}
+	return s.consumer.ConsumeBatches(topic, s.batchSize, s.batchWait, handler)
+}
+
+func (s *KafkaServiceImplementation) Done() <-chan struct{} {
+	return s.consumer.Done()
+}
+
 func (s *KafkaServiceImplementation) Close() {
 	_ = s.consumer.Close()
 	_ = s.producer.Close()
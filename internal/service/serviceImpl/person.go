@@ -2,38 +2,54 @@ package serviceImpl
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fio_finder/internal/config"
+	"fio_finder/internal/enrichment"
 	"fio_finder/internal/models"
 	"fio_finder/internal/repository"
 	"fio_finder/internal/service"
 	"fio_finder/pkg/cache"
 	"fio_finder/pkg/errors/repositoryErrors"
 	"fio_finder/pkg/logger"
+	"fio_finder/pkg/normalize"
 	"fmt"
-	"net/http"
-	"net/url"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
 type personServiceImplementation struct {
 	personRepository repository.PersonRepository
+	enricher         enrichment.Enricher
 	logger           *logger.Logger
 	cache            cache.Cache
 	ttlCache         time.Duration
+	genderRules      config.GenderRulesConfig
+	persons          config.PersonsConfig
 }
 
-func NewPersonServiceImplementation(personRepository repository.PersonRepository, logger *logger.Logger, cache cache.Cache, ttlCache time.Duration) service.PersonService {
+func NewPersonServiceImplementation(personRepository repository.PersonRepository, enricher enrichment.Enricher, logger *logger.Logger, cache cache.Cache, ttlCache time.Duration,
+	genderRules config.GenderRulesConfig, persons config.PersonsConfig) service.PersonService {
 	return &personServiceImplementation{
 		personRepository: personRepository,
+		enricher:         enricher,
 		logger:           logger,
 		cache:            cache,
 		ttlCache:         ttlCache,
+		genderRules:      genderRules,
+		persons:          persons,
 	}
 }
 
+// cleanNames trims the names of a person before it is enriched or stored.
+func cleanNames(person *models.Person) {
+	person.Name = normalize.Clean(person.Name)
+	person.Surname = normalize.Clean(person.Surname)
+	person.Patronymic = normalize.Clean(person.Patronymic)
+}
+
 func (p *personServiceImplementation) Create(ctx context.Context, person *models.Person) error {
+	cleanNames(person)
 	fields := map[string]interface{}{"name": person.Name, "surname": person.Surname}
 	err := p.personRepository.Create(ctx, person)
 	if err != nil {
@@ -44,84 +60,214 @@ func (p *personServiceImplementation) Create(ctx context.Context, person *models
 	return nil
 }
 
-type ageResponse struct {
-	Count int64  `json:"count"`
-	Name  string `json:"name"`
-	Age   uint64 `json:"age"`
-}
+func (p *personServiceImplementation) CreateWithEnrichment(ctx context.Context, person *models.Person) error {
+	cleanNames(person)
+	fields := map[string]interface{}{"name": person.Name, "surname": person.Surname}
+	if len(person.Name) == 0 || len(person.Surname) == 0 {
+		return repositoryErrors.MissingRequiredFields
+	}
 
-type genderResponse struct {
-	Count       int64   `json:"count"`
-	Name        string  `json:"name"`
-	Gender      string  `json:"gender"`
-	Probability float64 `json:"probability"`
-}
+	countryHint, err := normalizeCountryHint(person.CountryHint)
+	if err != nil {
+		return err
+	}
+	person.CountryHint = countryHint
 
-type country struct {
-	Country_id  string  `json:"country_id"`
-	Probability float64 `json:"probability"`
-}
+	res, err := enrich(ctx, p.enricher, p.genderRules, person)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person enrichment failed: " + err.Error())
+		return err
+	}
 
-type nationalityResponse struct {
-	Count   int64     `json:"count"`
-	Name    string    `json:"name"`
-	Country []country `json:"country"`
-}
+	person.Status = models.PersonStatusEnriched
+	now := time.Now()
+	if res.age != nil {
+		applyAge(person, res.age, now)
+	}
+	if res.gender != nil {
+		applyGender(person, res.gender, now)
+	}
+	if res.nationality != nil {
+		applyNationality(person, res.nationality, now)
+	}
 
-func getJson(url string, target interface{}) error {
-	r, err := http.Get(url)
+	err = p.personRepository.Create(ctx, person)
 	if err != nil {
+		p.logger.WithFields(fields).Error("person create failed: " + err.Error())
 		return err
 	}
-	defer r.Body.Close()
-	return json.NewDecoder(r.Body).Decode(target)
+	p.logger.WithFields(fields).Info("person create completed")
+	return nil
 }
 
-func (p *personServiceImplementation) CreateWithEnrichment(ctx context.Context, person *models.Person) error {
+func (p *personServiceImplementation) CreatePending(ctx context.Context, person *models.Person) error {
+	cleanNames(person)
 	fields := map[string]interface{}{"name": person.Name, "surname": person.Surname}
 	if len(person.Name) == 0 || len(person.Surname) == 0 {
 		return repositoryErrors.MissingRequiredFields
 	}
 
-	urlAge := "https://api.agify.io/?name=%s"
-	urlGender := "https://api.genderize.io/?name=%s"
-	urlNationality := "https://api.nationalize.io/?name=%s"
-	urlAge = fmt.Sprintf(urlAge, url.QueryEscape(person.Name))
-	urlGender = fmt.Sprintf(urlGender, url.QueryEscape(person.Name))
-	urlNationality = fmt.Sprintf(urlNationality, url.QueryEscape(person.Name))
-
-	ageResp := new(ageResponse)
-	err := getJson(urlAge, ageResp)
+	countryHint, err := normalizeCountryHint(person.CountryHint)
 	if err != nil {
-		p.logger.WithFields(fields).Error("get age from api failed: " + err.Error())
 		return err
 	}
+	person.CountryHint = countryHint
+	person.Status = models.PersonStatusPendingEnrichment
 
-	genderResp := new(genderResponse)
-	err = getJson(urlGender, genderResp)
+	err = p.personRepository.Create(ctx, person)
 	if err != nil {
-		p.logger.WithFields(fields).Error("get gender from api failed: " + err.Error())
+		p.logger.WithFields(fields).Error("pending person create failed: " + err.Error())
 		return err
 	}
+	p.logger.WithFields(fields).Info("pending person create completed")
+	return nil
+}
+
+func (p *personServiceImplementation) CreateMany(ctx context.Context, persons []models.Person) ([]uint64, error) {
+	fields := map[string]interface{}{"persons": len(persons)}
+	if len(persons) > models.MaxPersonBatchSize {
+		return nil, repositoryErrors.TooManyObjects
+	}
+
+	for i := range persons {
+		cleanNames(&persons[i])
+		if len(persons[i].Name) == 0 || len(persons[i].Surname) == 0 {
+			return nil, fmt.Errorf("person %d: %w", i, repositoryErrors.MissingRequiredFields)
+		}
+		countryHint, err := normalizeCountryHint(persons[i].CountryHint)
+		if err != nil {
+			return nil, fmt.Errorf("person %d: %w", i, err)
+		}
+		persons[i].CountryHint = countryHint
+	}
 
-	nationalityResp := new(nationalityResponse)
-	err = getJson(urlNationality, nationalityResp)
+	ids, err := p.personRepository.CreateMany(ctx, persons)
 	if err != nil {
-		p.logger.WithFields(fields).Error("get nationality from api failed: " + err.Error())
-		return err
+		p.logger.WithFields(fields).Error("person bulk create failed: " + err.Error())
+		return nil, err
 	}
+	p.evict(ctx, fields, personListCacheKey)
+	p.logger.WithFields(fields).Info("person bulk create completed")
+	return ids, nil
+}
 
-	person.Age = ageResp.Age
-	genderResp.Gender = strings.ToUpper(string(genderResp.Gender[0])) + genderResp.Gender[1:]
-	person.Gender = models.PersonGender(genderResp.Gender)
-	person.Nationality = nationalityResp.Country[0].Country_id
+func (p *personServiceImplementation) Upsert(ctx context.Context, person *models.Person) (bool, error) {
+	cleanNames(person)
+	fields := map[string]interface{}{"name": person.Name, "surname": person.Surname, "policy": p.persons.ConflictPolicy}
+	if len(person.Name) == 0 || len(person.Surname) == 0 {
+		return false, repositoryErrors.MissingRequiredFields
+	}
 
-	err = p.personRepository.Create(ctx, person)
+	countryHint, err := normalizeCountryHint(person.CountryHint)
 	if err != nil {
-		p.logger.WithFields(fields).Error("person create failed: " + err.Error())
-		return err
+		return false, err
 	}
-	p.logger.WithFields(fields).Info("person create completed")
+	person.CountryHint = countryHint
+
+	created, err := p.personRepository.Upsert(ctx, person, p.persons.ConflictPolicy)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person upsert failed: " + err.Error())
+		return false, err
+	}
+	fields["id"], fields["created"] = person.Id, created
+	if created {
+		p.evict(ctx, fields, personListCacheKey)
+	} else {
+		p.evict(ctx, fields, personCacheKey(person.Id), personListCacheKey)
+	}
+	p.logger.WithFields(fields).Info("person upsert completed")
+	return created, nil
+}
+
+func (p *personServiceImplementation) UpsertWithEnrichment(ctx context.Context, person *models.Person) (bool, error) {
+	known, err := p.IsKnown(ctx, person)
+	if err != nil {
+		return false, err
+	}
+	if known {
+		return p.Upsert(ctx, person)
+	}
+
+	err = p.CreateWithEnrichment(ctx, person)
+	if errors.Is(err, repositoryErrors.ObjectAlreadyExists) {
+		// Saved by someone else meanwhile, resolve the duplicate with the
+		// person enriched by now.
+		return p.Upsert(ctx, person)
+	}
+	return err == nil, err
+}
+
+func (p *personServiceImplementation) IsKnown(ctx context.Context, person *models.Person) (bool, error) {
+	cleanNames(person)
+	if len(person.Name) == 0 || len(person.Surname) == 0 {
+		return false, repositoryErrors.MissingRequiredFields
+	}
+	if p.persons.ConflictPolicy == config.ConflictPolicyOverwrite {
+		return false, nil
+	}
+
+	exists, err := p.personRepository.ExistsByNaturalKey(ctx, person)
+	if err != nil {
+		p.logger.WithFields(map[string]interface{}{"name": person.Name, "surname": person.Surname}).Error(
+			"person lookup failed: " + err.Error())
+		return false, err
+	}
+	return exists, nil
+}
+
+// BatchEnrich fills age, gender and nationality of the given persons in place
+// using multi-name provider requests, one set of requests per country hint.
+// Persons whose name is unknown to a provider keep the corresponding field
+// empty.
+func (p *personServiceImplementation) BatchEnrich(ctx context.Context, persons []models.Person) error {
+	fields := map[string]interface{}{"persons": len(persons)}
+
+	groups := make(map[string][]int)
+	for i := range persons {
+		cleanNames(&persons[i])
+		countryHint, err := normalizeCountryHint(persons[i].CountryHint)
+		if err != nil {
+			p.logger.WithFields(fields).Error("person batch enrichment failed: " + err.Error())
+			return err
+		}
+		persons[i].CountryHint = countryHint
+		groups[countryHint] = append(groups[countryHint], i)
+	}
+
+	now := time.Now()
+	for countryHint, indexes := range groups {
+		names := make([]string, 0, len(indexes))
+		genderNames := make([]string, 0, len(indexes))
+		ruled := make(map[int]*models.GenderEnrichment, len(indexes))
+		for _, i := range indexes {
+			names = append(names, enrichmentName(persons[i].Name))
+			ruled[i] = inferGender(p.genderRules, &persons[i])
+			if needsGenderProvider(p.genderRules, ruled[i]) {
+				genderNames = append(genderNames, enrichmentName(persons[i].Name))
+			}
+		}
+
+		res, err := enrichBatch(ctx, p.enricher, names, genderNames, countryHint)
+		if err != nil {
+			p.logger.WithFields(fields).Error("person batch enrichment failed: " + err.Error())
+			return err
+		}
+
+		for _, i := range indexes {
+			name := enrichmentName(persons[i].Name)
+			persons[i].Status = models.PersonStatusEnriched
+			if age, ok := res.ages[name]; ok {
+				applyAge(&persons[i], age, now)
+			}
+			if gender := chooseGender(p.genderRules, ruled[i], res.genders[name]); gender != nil {
+				applyGender(&persons[i], gender, now)
+			}
+			if nationality, ok := res.nationalities[name]; ok {
+				applyNationality(&persons[i], nationality, now)
+			}
+		}
+	}
+	p.logger.WithFields(fields).Info("person batch enrichment completed")
 	return nil
 }
 
@@ -132,26 +278,77 @@ func (p *personServiceImplementation) Delete(ctx context.Context, id uint64) err
 		p.logger.WithFields(fields).Error("person delete failed: " + err.Error())
 		return err
 	}
+	p.evict(ctx, fields, personCacheKey(id), personListCacheKey)
 	p.logger.WithFields(fields).Info("person delete completed")
 	return nil
 }
 
-func (p *personServiceImplementation) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate) error {
+func (p *personServiceImplementation) Restore(ctx context.Context, id uint64) error {
 	fields := map[string]interface{}{"id": id}
-	err := p.personRepository.Update(ctx, id, fieldsToUpdate)
+	err := p.personRepository.Restore(ctx, id)
 	if err != nil {
-		p.logger.WithFields(fields).Error("person update failed: " + err.Error())
+		p.logger.WithFields(fields).Error("person restore failed: " + err.Error())
 		return err
 	}
-	p.logger.WithFields(fields).Info("person update completed")
+	p.evict(ctx, fields, personListCacheKey)
+	p.logger.WithFields(fields).Info("person restore completed")
 	return nil
 }
 
+func (p *personServiceImplementation) Purge(ctx context.Context) (uint64, error) {
+	deletedBefore := time.Now().Add(-p.persons.DeletedRetention)
+	fields := map[string]interface{}{"deleted_before": deletedBefore}
+	count, err := p.personRepository.Purge(ctx, deletedBefore)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person purge failed: " + err.Error())
+		return 0, err
+	}
+	fields["purged"] = count
+	p.logger.WithFields(fields).Info("person purge completed")
+	return count, nil
+}
+
+const personListCacheKey = "persons"
+
+func personCacheKey(id uint64) string {
+	return "person:" + strconv.FormatUint(id, 10)
+}
+
+// evict drops cache entries that no longer reflect the stored persons. A
+// failed eviction is only logged since the entries expire anyway.
+func (p *personServiceImplementation) evict(ctx context.Context, fields map[string]interface{}, keys ...string) {
+	if p.cache == nil {
+		return
+	}
+	if err := p.cache.Delete(ctx, keys...); err != nil {
+		p.logger.WithFields(fields).Error("person cache eviction failed: " + err.Error())
+	}
+}
+
+func (p *personServiceImplementation) Update(ctx context.Context, id uint64, fieldsToUpdate models.PersonFieldsToUpdate, expectedVersion *uint64) (uint64, error) {
+	fields := map[string]interface{}{"id": id}
+	for _, field := range []models.PersonField{models.PersonFieldName, models.PersonFieldSurname, models.PersonFieldPatronymic} {
+		if value, ok := fieldsToUpdate[field].(string); ok {
+			fieldsToUpdate[field] = normalize.Clean(value)
+		}
+	}
+	version, err := p.personRepository.Update(ctx, id, fieldsToUpdate, expectedVersion)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person update failed: " + err.Error())
+		return 0, err
+	}
+	// A cached person would hand out an outdated version.
+	p.evict(ctx, fields, personCacheKey(id), personListCacheKey)
+	fields["version"] = version
+	p.logger.WithFields(fields).Info("person update completed")
+	return version, nil
+}
+
 func (p *personServiceImplementation) Get(ctx context.Context, id uint64) (*models.Person, error) {
 	fields := map[string]interface{}{"id": id}
 
 	if p.cache != nil {
-		cachedPerson, err := p.cache.Get(ctx, "person:"+strconv.Itoa(int(id)))
+		cachedPerson, err := p.cache.Get(ctx, personCacheKey(id))
 
 		if err == nil {
 			cachedData, ok := cachedPerson.(models.Person)
@@ -170,7 +367,7 @@ func (p *personServiceImplementation) Get(ctx context.Context, id uint64) (*mode
 	}
 
 	if p.cache != nil {
-		if err := p.cache.Set(ctx, "person:"+strconv.Itoa(int(id)), person, p.ttlCache); err != nil {
+		if err := p.cache.Set(ctx, personCacheKey(id), person, p.ttlCache); err != nil {
 			p.logger.WithFields(fields).Error("person caching failed: " + err.Error())
 		}
 	}
@@ -178,29 +375,263 @@ func (p *personServiceImplementation) Get(ctx context.Context, id uint64) (*mode
 	return person, nil
 }
 
-func (p *personServiceImplementation) GetList(ctx context.Context) ([]models.Person, error) {
-	if p.cache != nil {
-		cachedPerson, err := p.cache.Get(ctx, "persons")
+// GetHistory returns the recorded changes of a person, oldest first.
+func (p *personServiceImplementation) GetHistory(ctx context.Context, id uint64) ([]models.PersonChange, error) {
+	fields := map[string]interface{}{"id": id}
+	changes, err := p.personRepository.GetHistory(ctx, id)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person history get failed: " + err.Error())
+		return nil, err
+	}
+	p.logger.WithFields(fields).Info("person history get completed")
+	return changes, nil
+}
+
+func (p *personServiceImplementation) GetAsOf(ctx context.Context, id uint64, asOf time.Time) (*models.Person, error) {
+	fields := map[string]interface{}{"id": id, "as_of": asOf}
+	person, err := p.getAsOf(ctx, id, asOf)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person get as of failed: " + err.Error())
+		return nil, err
+	}
+	p.logger.WithFields(fields).Info("person get as of completed")
+	return person, nil
+}
+
+// getAsOf rebuilds a person from its history. A value at asOf is the old
+// value of the first later change of it, or the current value if it has not
+// changed since. This also works for persons older than the history, as
+// long as asOf is not older than the history.
+func (p *personServiceImplementation) getAsOf(ctx context.Context, id uint64, asOf time.Time) (*models.Person, error) {
+	changes, err := p.personRepository.GetHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	split := sort.Search(len(changes), func(i int) bool { return changes[i].ChangedAt.After(asOf) })
+	before, after := changes[:split], changes[split:]
+	if !existedAfter(before, after) {
+		return nil, repositoryErrors.ObjectDoesNotExists
+	}
+
+	values := make(map[string]any)
+	for _, c := range after {
+		for key, value := range c.OldValues {
+			if _, ok := values[key]; !ok {
+				values[key] = value
+			}
+		}
+	}
+
+	person := &models.Person{Id: id}
+	for key := range personValueSetters {
+		if _, ok := values[key]; !ok {
+			// Some values did not change since, take them from the person
+			// as it is now. A person deleted since has them all above.
+			if person, err = p.personRepository.Get(ctx, id); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	for key, value := range values {
+		if err := setPersonValue(person, key, value); err != nil {
+			return nil, err
+		}
+	}
+	person.Version = versionAfter(before, after, person.Version)
+	person.SearchKey = normalize.SearchKey(person.Surname, person.Name, person.Patronymic)
+	// Enrichment records and nationality candidates are not in the history.
+	person.Enrichments = nil
+	person.NationalityCandidates = nil
+	return person, nil
+}
+
+// existedAfter tells whether a person existed after the changes before,
+// with the changes after following.
+func existedAfter(before, after []models.PersonChange) bool {
+	if len(before) > 0 {
+		switch before[len(before)-1].Action {
+		case models.PersonActionDelete, models.PersonActionPurge:
+			return false
+		}
+		return true
+	}
+	if len(after) > 0 {
+		switch after[0].Action {
+		case models.PersonActionCreate, models.PersonActionRestore:
+			return false
+		}
+	}
+	return true
+}
+
+// versionAfter returns the version of a person after the changes before,
+// with the changes after following and current as the version now.
+func versionAfter(before, after []models.PersonChange, current uint64) uint64 {
+	if len(before) > 0 {
+		return before[len(before)-1].Version
+	}
+	if len(after) > 0 {
+		// Only updates bump the version.
+		if after[0].Action == models.PersonActionUpdate {
+			return after[0].Version - 1
+		}
+		return after[0].Version
+	}
+	return current
+}
+
+var personValueSetters = map[string]func(person *models.Person, value any) error{
+	models.PersonValueName:       func(person *models.Person, value any) error { return setString(&person.Name, value) },
+	models.PersonValueSurname:    func(person *models.Person, value any) error { return setString(&person.Surname, value) },
+	models.PersonValuePatronymic: func(person *models.Person, value any) error { return setString(&person.Patronymic, value) },
+	models.PersonValueAge: func(person *models.Person, value any) error {
+		person.Age = nil
+		if value == nil {
+			return nil
+		}
+		age, ok := value.(float64)
+		if !ok || age < 0 {
+			return repositoryErrors.InvalidField
+		}
+		person.Age = new(uint64)
+		*person.Age = uint64(age)
+		return nil
+	},
+	models.PersonValueGender: func(person *models.Person, value any) error {
+		person.Gender = nil
+		if value == nil {
+			return nil
+		}
+		gender, ok := value.(string)
+		if !ok {
+			return repositoryErrors.InvalidField
+		}
+		person.Gender = (*models.PersonGender)(&gender)
+		return nil
+	},
+	models.PersonValueNationality: func(person *models.Person, value any) error {
+		person.Nationality = nil
+		if value == nil {
+			return nil
+		}
+		nationality, ok := value.(string)
+		if !ok {
+			return repositoryErrors.InvalidField
+		}
+		person.Nationality = &nationality
+		return nil
+	},
+	models.PersonValueStatus: func(person *models.Person, value any) error {
+		var status string
+		err := setString(&status, value)
+		person.Status = models.PersonStatus(status)
+		return err
+	},
+	models.PersonValueCountryHint: func(person *models.Person, value any) error { return setString(&person.CountryHint, value) },
+	models.PersonValueExternalId:  func(person *models.Person, value any) error { return setString(&person.ExternalId, value) },
+}
+
+// setPersonValue sets a value recorded in the person history. Unknown keys
+// are ignored.
+func setPersonValue(person *models.Person, key string, value any) error {
+	setter, ok := personValueSetters[key]
+	if !ok {
+		return nil
+	}
+	return setter(person, value)
+}
+
+func setString(field *string, value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return repositoryErrors.InvalidField
+	}
+	*field = s
+	return nil
+}
+
+// GetList caches only the first page of the unfiltered list.
+func (p *personServiceImplementation) GetList(ctx context.Context, filter models.PersonFilter) (*models.PersonList, error) {
+	cacheable := p.cache != nil && filter == (models.PersonFilter{})
+	if err := completePersonFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		cachedPersons, err := p.cache.Get(ctx, personListCacheKey)
 
 		if err == nil {
-			cachedData, ok := cachedPerson.([]models.Person)
+			cachedData, ok := cachedPersons.(models.PersonList)
 			if ok {
-				return cachedData, nil
+				return &cachedData, nil
 			}
 		}
 	}
-	persons, err := p.personRepository.GetList(ctx)
+	persons, err := p.personRepository.GetList(ctx, filter)
 
 	if err != nil {
 		p.logger.Error("person get list failed: " + err.Error())
 		return persons, err
 	}
 
-	if p.cache != nil {
-		if err := p.cache.Set(ctx, "persons", persons, p.ttlCache); err != nil {
+	if cacheable {
+		if err := p.cache.Set(ctx, personListCacheKey, *persons, p.ttlCache); err != nil {
 			p.logger.Error("person list caching failed: " + err.Error())
 		}
 	}
 	p.logger.Info("person get list completed")
 	return persons, nil
 }
+
+func (p *personServiceImplementation) Search(ctx context.Context, query string, limit int) ([]models.PersonMatch, error) {
+	fields := map[string]interface{}{"query": query}
+	if normalize.Clean(query) == "" {
+		return nil, repositoryErrors.MissingRequiredFields
+	}
+	if limit == 0 {
+		limit = models.DefaultPersonSearchLimit
+	}
+	if limit < 0 || limit > models.MaxPersonSearchLimit {
+		return nil, repositoryErrors.InvalidFilter
+	}
+
+	matches, err := p.personRepository.Search(ctx, query, limit)
+	if err != nil {
+		p.logger.WithFields(fields).Error("person search failed: " + err.Error())
+		return nil, err
+	}
+	p.logger.WithFields(fields).Info("person search completed")
+	return matches, nil
+}
+
+// completePersonFilter fills in the default order and page size and rejects
+// filters the repository cannot serve.
+func completePersonFilter(filter *models.PersonFilter) error {
+	if filter.SortField == "" {
+		filter.SortField = models.PersonSortId
+	}
+	if filter.SortDirection == "" {
+		filter.SortDirection = models.SortAscending
+	}
+	if filter.Limit == 0 {
+		filter.Limit = models.DefaultPersonListLimit
+	}
+
+	switch {
+	case filter.SortDirection != models.SortAscending && filter.SortDirection != models.SortDescending,
+		filter.Limit < 0 || filter.Limit > models.MaxPersonListLimit,
+		filter.Offset < 0,
+		filter.After != "" && filter.Offset > 0,
+		filter.MinAge != nil && filter.MaxAge != nil && *filter.MinAge > *filter.MaxAge:
+		return repositoryErrors.InvalidFilter
+	}
+	switch filter.SortField {
+	case models.PersonSortId, models.PersonSortName, models.PersonSortSurname, models.PersonSortPatronymic,
+		models.PersonSortAge, models.PersonSortGender, models.PersonSortNationality:
+		return nil
+	default:
+		return repositoryErrors.InvalidFilter
+	}
+}
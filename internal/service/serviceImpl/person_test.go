@@ -2,30 +2,91 @@ package serviceImpl
 
 import (
 	"context"
+	"fio_finder/internal/config"
+	mock_enrichment "fio_finder/internal/enrichment/mocks"
 	"fio_finder/internal/models"
 	mock_repository "fio_finder/internal/repository/mocks"
 	"fio_finder/internal/service"
+	"fio_finder/pkg/cache"
+	"fio_finder/pkg/errors/enrichmentErrors"
 	"fio_finder/pkg/errors/repositoryErrors"
 	"fio_finder/pkg/logger"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"sync"
 	"testing"
+	"time"
 )
 
 type personServiceFields struct {
 	personRepositoryMock *mock_repository.MockPersonRepository
+	enricherMock         *mock_enrichment.MockEnricher
+	genderRules          config.GenderRulesConfig
+	persons              config.PersonsConfig
+	cache                cache.Cache
+}
+
+// memoryCache keeps values as they are, unlike the redis cache.
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string]interface{}
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.data[key]
+	if !ok {
+		return nil, fmt.Errorf("%s not cached", key)
+	}
+	return value, nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, key ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range key {
+		delete(c.data, k)
+	}
+	return nil
 }
 
 func createPersonServiceFields(controller *gomock.Controller) *personServiceFields {
 	fields := new(personServiceFields)
 
 	fields.personRepositoryMock = mock_repository.NewMockPersonRepository(controller)
+	fields.enricherMock = mock_enrichment.NewMockEnricher(controller)
 
 	return fields
 }
 
+// stripEnrichedAt zeroes enrichment timestamps so persons can be compared.
+func stripEnrichedAt(persons ...*models.Person) {
+	for _, p := range persons {
+		for i := range p.Enrichments {
+			p.Enrichments[i].EnrichedAt = time.Time{}
+		}
+	}
+}
+
+func probability(value float64) *float64 {
+	return &value
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
 func createPersonService(fields *personServiceFields) service.PersonService {
-	return NewPersonServiceImplementation(fields.personRepositoryMock, logger.New("/dev/null", ""), nil, 0)
+	return NewPersonServiceImplementation(fields.personRepositoryMock, fields.enricherMock, logger.New("/dev/null", ""), fields.cache, time.Minute, fields.genderRules, fields.persons)
 }
 
 var testCreateSuccess = []struct {
@@ -74,6 +135,384 @@ func TestPersonServiceImplementation_Create(t *testing.T) {
 
 }
 
+var testCreateWithEnrichmentSuccess = []struct {
+	TestName  string
+	InputData struct {
+		person *models.Person
+	}
+	Prepare     func(fields *personServiceFields)
+	CheckOutput func(t *testing.T, person *models.Person, err error)
+}{
+	{
+		TestName: "usual test",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Vasya", Surname: "Pupkin"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Vasya", "").Return(&models.AgeEnrichment{Age: 42, Count: 100, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Vasya", "").Return(&models.GenderEnrichment{
+				Gender: models.MaleUserGender, Probability: 0.99, Count: 100, Provider: "genderize",
+			}, nil)
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}, {CountryId: "KZ", Probability: 0.1}},
+				Count:     100, Provider: "nationalize",
+			}, nil)
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			stripEnrichedAt(person)
+			require.Equal(t, &models.Person{Name: "Vasya", Surname: "Pupkin", Age: ptr(uint64(42)), Gender: ptr(models.MaleUserGender), Nationality: ptr("RU"), Status: models.PersonStatusEnriched,
+				Enrichments: []models.FieldEnrichment{
+					{Field: models.EnrichedFieldAge, Provider: "agify", Count: 100},
+					{Field: models.EnrichedFieldGender, Provider: "genderize", Probability: probability(0.99), Count: 100},
+					{Field: models.EnrichedFieldNationality, Provider: "nationalize", Probability: probability(0.8), Count: 100},
+				},
+				NationalityCandidates: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}, {CountryId: "KZ", Probability: 0.1}},
+			}, person)
+		},
+	},
+	{
+		TestName: "country hint forwarded",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Aigerim", Surname: "Nurlanova", CountryHint: " kz"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Aigerim", "KZ").Return(&models.AgeEnrichment{Age: 30, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Aigerim", "KZ").Return(&models.GenderEnrichment{
+				Gender: models.FemaleUserGender, Probability: 0.98, Provider: "genderize",
+			}, nil)
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Aigerim").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "KZ", Probability: 0.9}}, Provider: "nationalize",
+			}, nil)
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			stripEnrichedAt(person)
+			require.Equal(t, "KZ", person.CountryHint)
+			require.Equal(t, []models.FieldEnrichment{
+				{Field: models.EnrichedFieldAge, Provider: "agify", CountryHint: "KZ"},
+				{Field: models.EnrichedFieldGender, Provider: "genderize", Probability: probability(0.98), CountryHint: "KZ"},
+				{Field: models.EnrichedFieldNationality, Provider: "nationalize", Probability: probability(0.9)},
+			}, person.Enrichments)
+		},
+	},
+	{
+		TestName: "skipped provider leaves field unenriched",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Vasya", Surname: "Pupkin"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Vasya", "").Return(&models.AgeEnrichment{Age: 42, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Vasya", "").Return(nil, &enrichmentErrors.ProviderError{
+				Provider: "genderize", Err: fmt.Errorf("%w, %w", enrichmentErrors.CircuitOpen, enrichmentErrors.FieldSkipped),
+			})
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Vasya").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}}, Provider: "nationalize",
+			}, nil)
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			stripEnrichedAt(person)
+			require.Equal(t, &models.Person{Name: "Vasya", Surname: "Pupkin", Age: ptr(uint64(42)), Nationality: ptr("RU"), Status: models.PersonStatusEnriched,
+				Enrichments: []models.FieldEnrichment{
+					{Field: models.EnrichedFieldAge, Provider: "agify"},
+					{Field: models.EnrichedFieldNationality, Provider: "nationalize", Probability: probability(0.8)},
+				},
+				NationalityCandidates: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+			}, person)
+		},
+	},
+	{
+		TestName: "unknown results stored as unknown",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Zyx", Surname: "Pupkin"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Zyx", "").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "agify", Err: enrichmentErrors.EmptyResult})
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Zyx", "").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "genderize", Err: enrichmentErrors.EmptyResult})
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Zyx").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "nationalize", Err: enrichmentErrors.EmptyResult})
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), &models.Person{
+				Name: "Zyx", Surname: "Pupkin", Status: models.PersonStatusEnriched,
+			}).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			require.Nil(t, person.Age)
+			require.Nil(t, person.Gender)
+			require.Nil(t, person.Nationality)
+		},
+	},
+	{
+		TestName: "gender rules take precedence over provider",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Sasha", Surname: "Ivanova", Patronymic: "Petrovna"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.genderRules = config.GenderRulesConfig{Enabled: true, Precedence: config.GenderPrecedenceRules}
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Sasha", "").Return(&models.AgeEnrichment{Age: 30, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Sasha").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "nationalize", Err: enrichmentErrors.EmptyResult})
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			stripEnrichedAt(person)
+			require.Equal(t, ptr(models.FemaleUserGender), person.Gender)
+			require.Equal(t, []models.FieldEnrichment{
+				{Field: models.EnrichedFieldAge, Provider: "agify"},
+				{Field: models.EnrichedFieldGender, Provider: "rules", Probability: probability(0.99)},
+			}, person.Enrichments)
+		},
+	},
+	{
+		TestName: "more confident gender wins",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Sasha", Surname: "Ivanova"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.genderRules = config.GenderRulesConfig{Enabled: true, Precedence: config.GenderPrecedenceConfident}
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Sasha", "").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "agify", Err: enrichmentErrors.EmptyResult})
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Sasha", "").Return(&models.GenderEnrichment{
+				Gender: models.MaleUserGender, Probability: 0.6, Provider: "genderize",
+			}, nil)
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Sasha").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "nationalize", Err: enrichmentErrors.EmptyResult})
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ptr(models.FemaleUserGender), person.Gender)
+			require.Equal(t, "rules", person.Enrichments[0].Provider)
+		},
+	},
+	{
+		TestName: "gender rules cover provider failure",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Nurlan", Surname: "Abenov", Patronymic: "Serikuly"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.genderRules = config.GenderRulesConfig{Enabled: true, Precedence: config.GenderPrecedenceApi}
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Nurlan", "").Return(&models.AgeEnrichment{Age: 40, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Nurlan", "").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "genderize", Err: context.DeadlineExceeded})
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Nurlan").Return(&models.NationalityEnrichment{
+				Countries: []models.CountryProbability{{CountryId: "KZ", Probability: 0.9}}, Provider: "nationalize",
+			}, nil)
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			require.Equal(t, ptr(models.MaleUserGender), person.Gender)
+		},
+	},
+	{
+		TestName: "cyrillic name transliterated for providers",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: " Иван ", Surname: "Иванов"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Ivan", "").Return(&models.AgeEnrichment{Age: 35, Provider: "agify"}, nil)
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Ivan", "").Return(&models.GenderEnrichment{
+				Gender: models.MaleUserGender, Probability: 0.99, Provider: "genderize",
+			}, nil)
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Ivan").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "nationalize", Err: enrichmentErrors.EmptyResult})
+			fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+		},
+		CheckOutput: func(t *testing.T, person *models.Person, err error) {
+			require.NoError(t, err)
+			require.Equal(t, "Иван", person.Name)
+			require.Equal(t, ptr(uint64(35)), person.Age)
+		},
+	},
+}
+
+var testCreateWithEnrichmentFailed = []struct {
+	TestName  string
+	InputData struct {
+		person *models.Person
+	}
+	Prepare     func(fields *personServiceFields)
+	CheckOutput func(t *testing.T, err error)
+}{
+	{
+		TestName: "missing surname",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Vasya"}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, repositoryErrors.MissingRequiredFields)
+		},
+	},
+	{
+		TestName: "invalid country hint",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Vasya", Surname: "Pupkin", CountryHint: "Kazakhstan"}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, enrichmentErrors.InvalidCountryHint)
+		},
+	},
+	{
+		TestName: "providers failed",
+		InputData: struct {
+			person *models.Person
+		}{person: &models.Person{Name: "Vasya", Surname: "Pupkin"}},
+		Prepare: func(fields *personServiceFields) {
+			fields.enricherMock.EXPECT().GetAge(context.Background(), "Vasya", "").Return(&models.AgeEnrichment{Age: 42}, nil)
+			fields.enricherMock.EXPECT().GetGender(context.Background(), "Vasya", "").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "genderize", Err: context.DeadlineExceeded})
+			fields.enricherMock.EXPECT().GetNationality(context.Background(), "Vasya").Return(nil,
+				&enrichmentErrors.ProviderError{Provider: "nationalize", Err: enrichmentErrors.UnexpectedStatus})
+		},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			require.ErrorIs(t, err, enrichmentErrors.UnexpectedStatus)
+			require.ErrorContains(t, err, "genderize")
+			require.ErrorContains(t, err, "nationalize")
+		},
+	},
+}
+
+func TestPersonServiceImplementation_CreateWithEnrichment(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range testCreateWithEnrichmentSuccess {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			personService := createPersonService(fields)
+
+			err := personService.CreateWithEnrichment(context.Background(), tt.InputData.person)
+
+			tt.CheckOutput(t, tt.InputData.person, err)
+		})
+	}
+	for _, tt := range testCreateWithEnrichmentFailed {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			personService := createPersonService(fields)
+
+			err := personService.CreateWithEnrichment(context.Background(), tt.InputData.person)
+
+			tt.CheckOutput(t, err)
+		})
+	}
+}
+
+func TestPersonServiceImplementation_CreatePending(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := createPersonServiceFields(ctrl)
+	fields.personRepositoryMock.EXPECT().Create(context.Background(), &models.Person{
+		Name: "Vasya", Surname: "Pupkin", CountryHint: "RU", Status: models.PersonStatusPendingEnrichment,
+	}).Return(nil)
+
+	personService := createPersonService(fields)
+
+	err := personService.CreatePending(context.Background(), &models.Person{Name: "Vasya", Surname: "Pupkin", CountryHint: "ru"})
+	require.NoError(t, err)
+	require.ErrorIs(t, personService.CreatePending(context.Background(), &models.Person{Name: "Vasya"}), repositoryErrors.MissingRequiredFields)
+}
+
+var testBatchEnrichSuccess = []struct {
+	TestName  string
+	InputData struct {
+		persons []models.Person
+	}
+	Prepare     func(fields *personServiceFields)
+	CheckOutput func(t *testing.T, persons []models.Person, err error)
+}{
+	{
+		TestName: "usual test",
+		InputData: struct {
+			persons []models.Person
+		}{persons: []models.Person{{Name: "Vasya", Surname: "Pupkin"}, {Name: "Masha", Surname: "Pupkina"}}},
+		Prepare: func(fields *personServiceFields) {
+			names := []string{"Vasya", "Masha"}
+			fields.enricherMock.EXPECT().GetAges(context.Background(), names, "").Return(map[string]*models.AgeEnrichment{
+				"Vasya": {Age: 42}, "Masha": {Age: 33},
+			}, nil)
+			fields.enricherMock.EXPECT().GetGenders(context.Background(), names, "").Return(map[string]*models.GenderEnrichment{
+				"Vasya": {Gender: models.MaleUserGender},
+			}, nil)
+			fields.enricherMock.EXPECT().GetNationalities(context.Background(), names).Return(map[string]*models.NationalityEnrichment{
+				"Vasya": {Countries: []models.CountryProbability{{CountryId: "RU"}}},
+				"Masha": {Countries: []models.CountryProbability{{CountryId: "KZ"}}},
+			}, nil)
+		},
+		CheckOutput: func(t *testing.T, persons []models.Person, err error) {
+			require.NoError(t, err)
+			stripEnrichedAt(&persons[0], &persons[1])
+			require.Equal(t, []models.Person{
+				{Name: "Vasya", Surname: "Pupkin", Age: ptr(uint64(42)), Gender: ptr(models.MaleUserGender), Nationality: ptr("RU"), Status: models.PersonStatusEnriched,
+					Enrichments: []models.FieldEnrichment{
+						{Field: models.EnrichedFieldAge},
+						{Field: models.EnrichedFieldGender, Probability: probability(0)},
+						{Field: models.EnrichedFieldNationality, Probability: probability(0)},
+					},
+					NationalityCandidates: []models.CountryProbability{{CountryId: "RU"}}},
+				{Name: "Masha", Surname: "Pupkina", Age: ptr(uint64(33)), Nationality: ptr("KZ"), Status: models.PersonStatusEnriched,
+					Enrichments: []models.FieldEnrichment{
+						{Field: models.EnrichedFieldAge},
+						{Field: models.EnrichedFieldNationality, Probability: probability(0)},
+					},
+					NationalityCandidates: []models.CountryProbability{{CountryId: "KZ"}}},
+			}, persons)
+		},
+	},
+}
+
+func TestPersonServiceImplementation_BatchEnrich(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range testBatchEnrichSuccess {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			personService := createPersonService(fields)
+
+			err := personService.BatchEnrich(context.Background(), tt.InputData.persons)
+
+			tt.CheckOutput(t, tt.InputData.persons, err)
+		})
+	}
+}
+
 var testGetSuccess = []struct {
 	TestName  string
 	InputData struct {
@@ -245,23 +684,49 @@ func TestPersonServiceImplementation_Delete(t *testing.T) {
 	}
 }
 
+var defaultPersonFilter = models.PersonFilter{
+	SortField:     models.PersonSortId,
+	SortDirection: models.SortAscending,
+	Limit:         models.DefaultPersonListLimit,
+}
+
 var testGetListSuccess = []struct {
 	TestName  string
 	InputData struct {
+		filter models.PersonFilter
 	}
 	Prepare     func(fields *personServiceFields)
-	CheckOutput func(t *testing.T, persons []models.Person, err error)
+	CheckOutput func(t *testing.T, persons *models.PersonList, err error)
 }{
 	{
 		TestName: "usual test",
 		InputData: struct {
+			filter models.PersonFilter
 		}{},
 		Prepare: func(fields *personServiceFields) {
-			fields.personRepositoryMock.EXPECT().GetList(context.Background()).Return([]models.Person{{Name: "Vasya", Surname: "Pupkin"}}, nil)
+			fields.personRepositoryMock.EXPECT().GetList(context.Background(), defaultPersonFilter).Return(&models.PersonList{
+				Persons: []models.Person{{Name: "Vasya", Surname: "Pupkin"}}, Total: 1,
+			}, nil)
 		},
-		CheckOutput: func(t *testing.T, persons []models.Person, err error) {
+		CheckOutput: func(t *testing.T, persons *models.PersonList, err error) {
+			require.NoError(t, err)
+			require.Equal(t, &models.PersonList{Persons: []models.Person{{Name: "Vasya", Surname: "Pupkin"}}, Total: 1}, persons)
+		},
+	},
+	{
+		TestName: "filtered and sorted page",
+		InputData: struct {
+			filter models.PersonFilter
+		}{filter: models.PersonFilter{MinAge: ptr(uint64(20)), MaxAge: ptr(uint64(30)), SortField: models.PersonSortAge,
+			SortDirection: models.SortDescending, Limit: 10, Offset: 20}},
+		Prepare: func(fields *personServiceFields) {
+			fields.personRepositoryMock.EXPECT().GetList(context.Background(), models.PersonFilter{MinAge: ptr(uint64(20)), MaxAge: ptr(uint64(30)),
+				SortField: models.PersonSortAge, SortDirection: models.SortDescending, Limit: 10, Offset: 20,
+			}).Return(&models.PersonList{Total: 25}, nil)
+		},
+		CheckOutput: func(t *testing.T, persons *models.PersonList, err error) {
 			require.NoError(t, err)
-			require.Equal(t, []models.Person{{Name: "Vasya", Surname: "Pupkin"}}, persons)
+			require.Equal(t, uint64(25), persons.Total)
 		},
 	},
 }
@@ -269,6 +734,7 @@ var testGetListSuccess = []struct {
 var testGetListFailed = []struct {
 	TestName  string
 	InputData struct {
+		filter models.PersonFilter
 	}
 	Prepare     func(fields *personServiceFields)
 	CheckOutput func(t *testing.T, err error)
@@ -276,14 +742,55 @@ var testGetListFailed = []struct {
 	{
 		TestName: "person does not exists",
 		InputData: struct {
+			filter models.PersonFilter
 		}{},
 		Prepare: func(fields *personServiceFields) {
-			fields.personRepositoryMock.EXPECT().GetList(context.Background()).Return(nil, repositoryErrors.ObjectDoesNotExists)
+			fields.personRepositoryMock.EXPECT().GetList(context.Background(), defaultPersonFilter).Return(nil, repositoryErrors.ObjectDoesNotExists)
 		},
 		CheckOutput: func(t *testing.T, err error) {
 			require.ErrorIs(t, err, repositoryErrors.ObjectDoesNotExists)
 		},
 	},
+	{
+		TestName: "unknown sort field",
+		InputData: struct {
+			filter models.PersonFilter
+		}{filter: models.PersonFilter{SortField: "status"}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, repositoryErrors.InvalidFilter)
+		},
+	},
+	{
+		TestName: "limit too large",
+		InputData: struct {
+			filter models.PersonFilter
+		}{filter: models.PersonFilter{Limit: models.MaxPersonListLimit + 1}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, repositoryErrors.InvalidFilter)
+		},
+	},
+	{
+		TestName: "cursor combined with offset",
+		InputData: struct {
+			filter models.PersonFilter
+		}{filter: models.PersonFilter{After: "eyJpIjoxfQ", Offset: 10}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, repositoryErrors.InvalidFilter)
+		},
+	},
+	{
+		TestName: "age range reversed",
+		InputData: struct {
+			filter models.PersonFilter
+		}{filter: models.PersonFilter{MinAge: ptr(uint64(30)), MaxAge: ptr(uint64(20))}},
+		Prepare: func(fields *personServiceFields) {},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, repositoryErrors.InvalidFilter)
+		},
+	},
 }
 
 func TestPersonServiceImplementation_GetList(t *testing.T) {
@@ -302,7 +809,7 @@ func TestPersonServiceImplementation_GetList(t *testing.T) {
 
 			personService := createPersonService(fields)
 
-			p, err := personService.GetList(context.Background())
+			p, err := personService.GetList(context.Background(), tt.InputData.filter)
 
 			tt.CheckOutput(t, p, err)
 		})
@@ -320,7 +827,7 @@ func TestPersonServiceImplementation_GetList(t *testing.T) {
 
 			personService := createPersonService(fields)
 
-			_, err := personService.GetList(context.Background())
+			_, err := personService.GetList(context.Background(), tt.InputData.filter)
 
 			tt.CheckOutput(t, err)
 		})
@@ -328,11 +835,462 @@ func TestPersonServiceImplementation_GetList(t *testing.T) {
 
 }
 
+func TestPersonServiceImplementation_Search(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		TestName    string
+		Query       string
+		Limit       int
+		Prepare     func(fields *personServiceFields)
+		CheckOutput func(t *testing.T, matches []models.PersonMatch, err error)
+	}{
+		{
+			TestName: "default limit",
+			Query:    "Ivonov",
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().Search(context.Background(), "Ivonov", models.DefaultPersonSearchLimit).Return([]models.PersonMatch{
+					{Person: models.Person{Name: "Ivan", Surname: "Ivanov"}, Score: 0.57},
+				}, nil)
+			},
+			CheckOutput: func(t *testing.T, matches []models.PersonMatch, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []models.PersonMatch{{Person: models.Person{Name: "Ivan", Surname: "Ivanov"}, Score: 0.57}}, matches)
+			},
+		},
+		{
+			TestName: "empty query",
+			Query:    "  ",
+			Prepare:  func(fields *personServiceFields) {},
+			CheckOutput: func(t *testing.T, matches []models.PersonMatch, err error) {
+				require.ErrorIs(t, err, repositoryErrors.MissingRequiredFields)
+			},
+		},
+		{
+			TestName: "limit too large",
+			Query:    "Ivan",
+			Limit:    models.MaxPersonSearchLimit + 1,
+			Prepare:  func(fields *personServiceFields) {},
+			CheckOutput: func(t *testing.T, matches []models.PersonMatch, err error) {
+				require.ErrorIs(t, err, repositoryErrors.InvalidFilter)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			personService := createPersonService(fields)
+
+			matches, err := personService.Search(context.Background(), tt.Query, tt.Limit)
+
+			tt.CheckOutput(t, matches, err)
+		})
+	}
+}
+
+func TestPersonServiceImplementation_DeleteEvictsCache(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := createPersonServiceFields(ctrl)
+	cached := &memoryCache{data: map[string]interface{}{
+		"person:1": models.Person{Id: 1},
+		"person:2": models.Person{Id: 2},
+		"persons":  models.PersonList{Persons: []models.Person{{Id: 1}, {Id: 2}}, Total: 2},
+	}}
+	fields.cache = cached
+	fields.personRepositoryMock.EXPECT().Delete(context.Background(), uint64(1)).Return(nil)
+	fields.personRepositoryMock.EXPECT().Restore(context.Background(), uint64(1)).Return(nil)
+
+	personService := createPersonService(fields)
+
+	require.NoError(t, personService.Delete(context.Background(), 1))
+	require.NotContains(t, cached.data, "person:1")
+	require.NotContains(t, cached.data, "persons")
+	require.Contains(t, cached.data, "person:2")
+
+	cached.data["persons"] = models.PersonList{Persons: []models.Person{{Id: 2}}, Total: 1}
+	require.NoError(t, personService.Restore(context.Background(), 1))
+	require.NotContains(t, cached.data, "persons")
+}
+
+func TestPersonServiceImplementation_Purge(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := createPersonServiceFields(ctrl)
+	fields.personRepositoryMock.EXPECT().Purge(context.Background(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, deletedBefore time.Time) (uint64, error) {
+			require.WithinDuration(t, time.Now(), deletedBefore, time.Minute)
+			return 3, nil
+		})
+
+	count, err := createPersonService(fields).Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+}
+
+func TestPersonServiceImplementation_CreateMany(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		TestName    string
+		InputData   []models.Person
+		Prepare     func(fields *personServiceFields)
+		CheckOutput func(t *testing.T, ids []uint64, err error)
+	}{
+		{
+			TestName:  "usual test",
+			InputData: []models.Person{{Name: " Vasya ", Surname: "Pupkin"}, {Name: "Masha", Surname: "Ivanova", CountryHint: "ru"}},
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().CreateMany(context.Background(), []models.Person{
+					{Name: "Vasya", Surname: "Pupkin"},
+					{Name: "Masha", Surname: "Ivanova", CountryHint: "RU"},
+				}).Return([]uint64{7, 8}, nil)
+			},
+			CheckOutput: func(t *testing.T, ids []uint64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []uint64{7, 8}, ids)
+			},
+		},
+		{
+			TestName:  "missing surname",
+			InputData: []models.Person{{Name: "Vasya", Surname: "Pupkin"}, {Name: "Masha", Surname: " "}},
+			Prepare:   func(fields *personServiceFields) {},
+			CheckOutput: func(t *testing.T, ids []uint64, err error) {
+				require.ErrorIs(t, err, repositoryErrors.MissingRequiredFields)
+				require.ErrorContains(t, err, "person 1")
+			},
+		},
+		{
+			TestName:  "too many persons",
+			InputData: make([]models.Person, models.MaxPersonBatchSize+1),
+			Prepare:   func(fields *personServiceFields) {},
+			CheckOutput: func(t *testing.T, ids []uint64, err error) {
+				require.ErrorIs(t, err, repositoryErrors.TooManyObjects)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			ids, err := createPersonService(fields).CreateMany(context.Background(), tt.InputData)
+			tt.CheckOutput(t, ids, err)
+		})
+	}
+}
+
+func TestPersonServiceImplementation_Upsert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		TestName    string
+		InputData   *models.Person
+		Prepare     func(fields *personServiceFields)
+		CheckOutput func(t *testing.T, created bool, err error)
+	}{
+		{
+			TestName:  "new person",
+			InputData: &models.Person{Name: " Vasya", Surname: "Pupkin", ExternalId: "crm-1"},
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().Upsert(context.Background(),
+					&models.Person{Name: "Vasya", Surname: "Pupkin", ExternalId: "crm-1"}, config.ConflictPolicyMerge).Return(true, nil)
+			},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.NoError(t, err)
+				require.True(t, created)
+			},
+		},
+		{
+			TestName:  "existing person",
+			InputData: &models.Person{Name: "Vasya", Surname: "Pupkin"},
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().Upsert(context.Background(), gomock.Any(), config.ConflictPolicyMerge).DoAndReturn(
+					func(_ context.Context, person *models.Person, _ string) (bool, error) {
+						person.Id = 1
+						return false, nil
+					})
+			},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.NoError(t, err)
+				require.False(t, created)
+			},
+		},
+		{
+			TestName:  "missing surname",
+			InputData: &models.Person{Name: "Vasya"},
+			Prepare:   func(fields *personServiceFields) {},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.ErrorIs(t, err, repositoryErrors.MissingRequiredFields)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			fields.persons.ConflictPolicy = config.ConflictPolicyMerge
+			tt.Prepare(fields)
+
+			created, err := createPersonService(fields).Upsert(context.Background(), tt.InputData)
+			tt.CheckOutput(t, created, err)
+		})
+	}
+}
+
+func TestPersonServiceImplementation_UpsertWithEnrichment(t *testing.T) {
+	t.Parallel()
+
+	expectEnrichment := func(fields *personServiceFields) {
+		fields.enricherMock.EXPECT().GetAge(gomock.Any(), "Vasya", "").Return(&models.AgeEnrichment{Age: 30}, nil)
+		fields.enricherMock.EXPECT().GetGender(gomock.Any(), "Vasya", "").Return(&models.GenderEnrichment{Gender: models.MaleUserGender}, nil)
+		fields.enricherMock.EXPECT().GetNationality(gomock.Any(), "Vasya").Return(&models.NationalityEnrichment{
+			Countries: []models.CountryProbability{{CountryId: "RU", Probability: 0.8}},
+		}, nil)
+	}
+
+	tests := []struct {
+		TestName    string
+		Policy      string
+		InputData   *models.Person
+		Prepare     func(fields *personServiceFields)
+		CheckOutput func(t *testing.T, created bool, err error)
+	}{
+		{
+			TestName:  "new person is enriched",
+			Policy:    config.ConflictPolicyMerge,
+			InputData: &models.Person{Name: "Vasya", Surname: "Pupkin"},
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().ExistsByNaturalKey(context.Background(), gomock.Any()).Return(false, nil)
+				expectEnrichment(fields)
+				fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(nil)
+			},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.NoError(t, err)
+				require.True(t, created)
+			},
+		},
+		{
+			TestName:  "known person is merged without enrichment",
+			Policy:    config.ConflictPolicyMerge,
+			InputData: &models.Person{Name: "Vasya", Surname: "Pupkin"},
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().ExistsByNaturalKey(context.Background(), gomock.Any()).Return(true, nil)
+				fields.personRepositoryMock.EXPECT().Upsert(context.Background(),
+					&models.Person{Name: "Vasya", Surname: "Pupkin"}, config.ConflictPolicyMerge).Return(false, nil)
+			},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.NoError(t, err)
+				require.False(t, created)
+			},
+		},
+		{
+			TestName:  "known person is overwritten with enrichment",
+			Policy:    config.ConflictPolicyOverwrite,
+			InputData: &models.Person{Name: "Vasya", Surname: "Pupkin"},
+			Prepare: func(fields *personServiceFields) {
+				expectEnrichment(fields)
+				fields.personRepositoryMock.EXPECT().Create(context.Background(), gomock.Any()).Return(repositoryErrors.ObjectAlreadyExists)
+				fields.personRepositoryMock.EXPECT().Upsert(context.Background(), gomock.Any(), config.ConflictPolicyOverwrite).DoAndReturn(
+					func(_ context.Context, person *models.Person, _ string) (bool, error) {
+						require.NotNil(t, person.Age)
+						return false, nil
+					})
+			},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.NoError(t, err)
+				require.False(t, created)
+			},
+		},
+		{
+			TestName:  "missing surname",
+			Policy:    config.ConflictPolicyMerge,
+			InputData: &models.Person{Name: "Vasya"},
+			Prepare:   func(fields *personServiceFields) {},
+			CheckOutput: func(t *testing.T, created bool, err error) {
+				require.ErrorIs(t, err, repositoryErrors.MissingRequiredFields)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			fields.persons.ConflictPolicy = tt.Policy
+			tt.Prepare(fields)
+
+			created, err := createPersonService(fields).UpsertWithEnrichment(context.Background(), tt.InputData)
+			tt.CheckOutput(t, created, err)
+		})
+	}
+}
+
+func TestPersonServiceImplementation_GetHistory(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	history := []models.PersonChange{
+		{PersonId: 1, Action: models.PersonActionCreate, Actor: "kafka", Version: 1, NewValues: map[string]any{"name": "Vasya"}},
+		{PersonId: 1, Action: models.PersonActionUpdate, Actor: "admin", Version: 2,
+			OldValues: map[string]any{"name": "Vasya"}, NewValues: map[string]any{"name": "Jora"}},
+	}
+
+	fields := createPersonServiceFields(ctrl)
+	fields.personRepositoryMock.EXPECT().GetHistory(context.Background(), uint64(1)).Return(history, nil)
+	fields.personRepositoryMock.EXPECT().GetHistory(context.Background(), uint64(2)).Return(nil, repositoryErrors.ObjectDoesNotExists)
+
+	service := createPersonService(fields)
+	changes, err := service.GetHistory(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, history, changes)
+
+	_, err = service.GetHistory(context.Background(), 2)
+	require.ErrorIs(t, err, repositoryErrors.ObjectDoesNotExists)
+}
+
+func TestPersonServiceImplementation_GetAsOf(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	updated := created.Add(24 * time.Hour)
+	deleted := updated.Add(24 * time.Hour)
+	age := uint64(30)
+	gender := models.MaleUserGender
+
+	snapshot := map[string]any{
+		models.PersonValueName: "Jora", models.PersonValueSurname: "Pupkin", models.PersonValuePatronymic: "",
+		models.PersonValueAge: float64(30), models.PersonValueGender: "Male", models.PersonValueNationality: nil,
+		models.PersonValueStatus: "manual", models.PersonValueCountryHint: "", models.PersonValueExternalId: "",
+	}
+	history := []models.PersonChange{
+		{Action: models.PersonActionCreate, Version: 1, ChangedAt: created, NewValues: map[string]any{}},
+		{Action: models.PersonActionUpdate, Version: 2, ChangedAt: updated,
+			OldValues: map[string]any{models.PersonValueName: "Vasya", models.PersonValueAge: nil},
+			NewValues: map[string]any{models.PersonValueName: "Jora", models.PersonValueAge: float64(30)}},
+	}
+	deletedHistory := append(history[:2:2], models.PersonChange{
+		Action: models.PersonActionDelete, Version: 2, ChangedAt: deleted, OldValues: snapshot})
+
+	tests := []struct {
+		TestName    string
+		AsOf        time.Time
+		Prepare     func(fields *personServiceFields)
+		CheckOutput func(t *testing.T, person *models.Person, err error)
+	}{
+		{
+			TestName: "before an update",
+			AsOf:     created.Add(time.Hour),
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().GetHistory(context.Background(), uint64(1)).Return(history, nil)
+				fields.personRepositoryMock.EXPECT().Get(context.Background(), uint64(1)).Return(&models.Person{
+					Id: 1, Name: "Jora", Surname: "Pupkin", Age: &age, Gender: &gender,
+					Status: models.PersonStatusManual, Version: 2,
+					Enrichments: []models.FieldEnrichment{{Field: models.EnrichedFieldAge, Provider: models.ManualProvider}},
+				}, nil)
+			},
+			CheckOutput: func(t *testing.T, person *models.Person, err error) {
+				require.NoError(t, err)
+				require.Equal(t, &models.Person{
+					Id: 1, Name: "Vasya", Surname: "Pupkin", Gender: &gender,
+					Status: models.PersonStatusManual, SearchKey: "pupkin vasya", Version: 1,
+				}, person)
+			},
+		},
+		{
+			TestName: "before a delete",
+			AsOf:     updated.Add(time.Hour),
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().GetHistory(context.Background(), uint64(1)).Return(deletedHistory, nil)
+			},
+			CheckOutput: func(t *testing.T, person *models.Person, err error) {
+				require.NoError(t, err)
+				require.Equal(t, &models.Person{
+					Id: 1, Name: "Jora", Surname: "Pupkin", Age: &age, Gender: &gender,
+					Status: models.PersonStatusManual, SearchKey: "pupkin jora", Version: 2,
+				}, person)
+			},
+		},
+		{
+			TestName: "before creation",
+			AsOf:     created.Add(-time.Hour),
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().GetHistory(context.Background(), uint64(1)).Return(history, nil)
+			},
+			CheckOutput: func(t *testing.T, person *models.Person, err error) {
+				require.ErrorIs(t, err, repositoryErrors.ObjectDoesNotExists)
+			},
+		},
+		{
+			TestName: "after a delete",
+			AsOf:     deleted.Add(time.Hour),
+			Prepare: func(fields *personServiceFields) {
+				fields.personRepositoryMock.EXPECT().GetHistory(context.Background(), uint64(1)).Return(deletedHistory, nil)
+			},
+			CheckOutput: func(t *testing.T, person *models.Person, err error) {
+				require.ErrorIs(t, err, repositoryErrors.ObjectDoesNotExists)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fields := createPersonServiceFields(ctrl)
+			tt.Prepare(fields)
+
+			person, err := createPersonService(fields).GetAsOf(context.Background(), 1, tt.AsOf)
+			tt.CheckOutput(t, person, err)
+		})
+	}
+}
+
 var testUpdateSuccess = []struct {
 	TestName  string
 	InputData struct {
-		id             uint64
-		fieldsToUpdate models.PersonFieldsToUpdate
+		id              uint64
+		fieldsToUpdate  models.PersonFieldsToUpdate
+		expectedVersion *uint64
 	}
 	Prepare     func(fields *personServiceFields)
 	CheckOutput func(t *testing.T, err error)
@@ -340,11 +1298,12 @@ var testUpdateSuccess = []struct {
 	{
 		TestName: "usual test",
 		InputData: struct {
-			id             uint64
-			fieldsToUpdate models.PersonFieldsToUpdate
+			id              uint64
+			fieldsToUpdate  models.PersonFieldsToUpdate
+			expectedVersion *uint64
 		}{id: 1, fieldsToUpdate: map[models.PersonField]any{models.PersonFieldName: "Jora"}},
 		Prepare: func(fields *personServiceFields) {
-			fields.personRepositoryMock.EXPECT().Update(context.Background(), uint64(1), map[models.PersonField]any{models.PersonFieldName: "Jora"}).Return(nil)
+			fields.personRepositoryMock.EXPECT().Update(context.Background(), uint64(1), map[models.PersonField]any{models.PersonFieldName: "Jora"}, nil).Return(uint64(2), nil)
 		},
 		CheckOutput: func(t *testing.T, err error) {
 			require.NoError(t, err)
@@ -355,8 +1314,9 @@ var testUpdateSuccess = []struct {
 var testUpdateFailed = []struct {
 	TestName  string
 	InputData struct {
-		id             uint64
-		fieldsToUpdate models.PersonFieldsToUpdate
+		id              uint64
+		fieldsToUpdate  models.PersonFieldsToUpdate
+		expectedVersion *uint64
 	}
 	Prepare     func(fields *personServiceFields)
 	CheckOutput func(t *testing.T, err error)
@@ -364,16 +1324,35 @@ var testUpdateFailed = []struct {
 	{
 		TestName: "person does not exists",
 		InputData: struct {
-			id             uint64
-			fieldsToUpdate models.PersonFieldsToUpdate
+			id              uint64
+			fieldsToUpdate  models.PersonFieldsToUpdate
+			expectedVersion *uint64
 		}{id: 1, fieldsToUpdate: map[models.PersonField]any{models.PersonFieldName: "Jora"}},
 		Prepare: func(fields *personServiceFields) {
-			fields.personRepositoryMock.EXPECT().Update(context.Background(), uint64(1), map[models.PersonField]any{models.PersonFieldName: "Jora"}).Return(repositoryErrors.ObjectDoesNotExists)
+			fields.personRepositoryMock.EXPECT().Update(context.Background(), uint64(1), map[models.PersonField]any{models.PersonFieldName: "Jora"}, nil).Return(uint64(0), repositoryErrors.ObjectDoesNotExists)
 		},
 		CheckOutput: func(t *testing.T, err error) {
 			require.ErrorIs(t, err, repositoryErrors.ObjectDoesNotExists)
 		},
 	},
+	{
+		TestName: "version conflict",
+		InputData: struct {
+			id              uint64
+			fieldsToUpdate  models.PersonFieldsToUpdate
+			expectedVersion *uint64
+		}{id: 1, fieldsToUpdate: map[models.PersonField]any{models.PersonFieldName: "Jora"}, expectedVersion: ptr(uint64(3))},
+		Prepare: func(fields *personServiceFields) {
+			fields.personRepositoryMock.EXPECT().Update(context.Background(), uint64(1), map[models.PersonField]any{models.PersonFieldName: "Jora"}, ptr(uint64(3))).
+				Return(uint64(0), &repositoryErrors.VersionConflictError{Expected: 3, Actual: 4})
+		},
+		CheckOutput: func(t *testing.T, err error) {
+			require.ErrorIs(t, err, repositoryErrors.VersionConflict)
+			var conflictErr *repositoryErrors.VersionConflictError
+			require.ErrorAs(t, err, &conflictErr)
+			require.Equal(t, uint64(4), conflictErr.Actual)
+		},
+	},
 }
 
 func TestPersonServiceImplementation_Update(t *testing.T) {
@@ -392,7 +1371,7 @@ func TestPersonServiceImplementation_Update(t *testing.T) {
 
 			personService := createPersonService(fields)
 
-			err := personService.Update(context.Background(), tt.InputData.id, tt.InputData.fieldsToUpdate)
+			_, err := personService.Update(context.Background(), tt.InputData.id, tt.InputData.fieldsToUpdate, tt.InputData.expectedVersion)
 
 			tt.CheckOutput(t, err)
 		})
@@ -409,7 +1388,7 @@ func TestPersonServiceImplementation_Update(t *testing.T) {
 			tt.Prepare(fields)
 
 			personService := createPersonService(fields)
-			err := personService.Update(context.Background(), tt.InputData.id, tt.InputData.fieldsToUpdate)
+			_, err := personService.Update(context.Background(), tt.InputData.id, tt.InputData.fieldsToUpdate, tt.InputData.expectedVersion)
 
 			tt.CheckOutput(t, err)
 		})
@@ -0,0 +1,52 @@
+// Package actor carries the name of whoever causes a change through a
+// context, so that the change can be attributed in the person history.
+package actor
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// Header is the request header clients name themselves in.
+	Header = "X-Actor"
+
+	// System is the actor of changes made without a known initiator.
+	System = "system"
+	// Anonymous is the actor of requests that did not name themselves.
+	Anonymous = "anonymous"
+	// Kafka is the actor of persons ingested from the FIO topic.
+	Kafka = "kafka"
+	// Enrichment is the actor of changes made by the enrichment workers.
+	Enrichment = "enrichment"
+)
+
+// maxLength bounds actors taken from request headers.
+const maxLength = 128
+
+type contextKey struct{}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// FromContext returns the actor stored in ctx, or System if there is none.
+func FromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(contextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return System
+}
+
+// FromHeader turns the value of the actor header into an actor. Missing
+// values are Anonymous and overlong ones are cut.
+func FromHeader(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Anonymous
+	}
+	if runes := []rune(value); len(runes) > maxLength {
+		value = string(runes[:maxLength])
+	}
+	return value
+}
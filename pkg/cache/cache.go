@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -10,3 +11,14 @@ type Cache interface {
 	Get(ctx context.Context, key string) (interface{}, error)
 	Delete(ctx context.Context, key ...string) error
 }
+
+// Decode converts a value returned by Cache.Get into target. Backends that
+// serialize values hand back generic JSON structures, so the value is
+// re-encoded and decoded into the concrete type.
+func Decode(value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fio_finder/internal/config"
 	"fio_finder/pkg/cache"
+	"github.com/redis/go-redis/v9"
 	"time"
 )
 
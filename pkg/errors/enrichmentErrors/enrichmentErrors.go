@@ -0,0 +1,56 @@
+package enrichmentErrors
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	UnexpectedStatus = errors.New("unexpected provider response status")
+
+	EmptyResult = errors.New("provider returned empty result")
+
+	InvalidCountryHint = errors.New("invalid country hint")
+
+	QuotaExceeded = errors.New("provider quota exceeded")
+
+	CircuitOpen = errors.New("provider circuit is open")
+
+	// FieldSkipped marks provider failures after which the person should be
+	// saved with the field left unenriched.
+	FieldSkipped = errors.New("field left unenriched")
+)
+
+// ProviderError tells which enrichment provider a failure came from.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProviderError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// QuotaError is returned when a provider's request quota is exhausted.
+// RetryAfter tells when the quota is expected to be available again.
+type QuotaError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return QuotaExceeded.Error() + ", retry after " + e.RetryAfter.Round(time.Second).String()
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == QuotaExceeded
+}
@@ -3,6 +3,7 @@ package repositoryErrors
 import (
 	"errors"
 	"fmt"
+	"strconv"
 )
 
 var (
@@ -11,7 +12,31 @@ var (
 	DoesNotExists       = errors.New("does not exists")
 	ObjectDoesNotExists = fmt.Errorf("object %w", DoesNotExists)
 
-	InvalidField = errors.New("invalid fields")
+	AlreadyExists       = errors.New("already exists")
+	ObjectAlreadyExists = fmt.Errorf("object %w", AlreadyExists)
+
+	InvalidField  = errors.New("invalid fields")
+	InvalidFilter = errors.New("invalid filter")
+	InvalidCursor = fmt.Errorf("cursor: %w", InvalidFilter)
 
 	MissingRequiredFields = errors.New("missing required fields")
+	TooManyObjects        = errors.New("too many objects")
+
+	VersionConflict = errors.New("version conflict")
 )
+
+// VersionConflictError is returned when an object was changed by someone
+// else since the caller read it. Actual is the version stored now.
+type VersionConflictError struct {
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *VersionConflictError) Error() string {
+	return VersionConflict.Error() + ": expected version " + strconv.FormatUint(e.Expected, 10) +
+		", actual " + strconv.FormatUint(e.Actual, 10)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == VersionConflict
+}
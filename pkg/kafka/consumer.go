@@ -3,6 +3,7 @@ package kafka
 import (
 	"fio_finder/pkg/logger"
 	"github.com/IBM/sarama"
+	"time"
 )
 
 type Consumer struct {
@@ -56,10 +57,83 @@ func (c *Consumer) consumePartition(pc sarama.PartitionConsumer, handler func(me
 	}
 }
 
+// ConsumeBatches delivers messages in groups of up to size. A group is flushed
+// early once wait has passed since its first message, so bursts are grouped
+// while single messages are not delayed for long.
+func (c *Consumer) ConsumeBatches(topic string, size int, wait time.Duration, handler func(messages []string)) error {
+	partitions, err := c.Consumer.Partitions(topic)
+	if err != nil {
+		c.Logger.Println("Failed to retrieve partitions:", err)
+		return err
+	}
+
+	for _, partition := range partitions {
+		pc, err := c.Consumer.ConsumePartition(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			c.Logger.Println("Failed to start consumer for partition", partition, ":", err)
+			return err
+		}
+		go c.consumePartitionBatches(pc, size, wait, handler)
+	}
+
+	return nil
+}
+
+func (c *Consumer) consumePartitionBatches(pc sarama.PartitionConsumer, size int, wait time.Duration, handler func(messages []string)) {
+	defer pc.Close()
+
+	batch := make([]string, 0, size)
+	timer := time.NewTimer(wait)
+	timer.Stop()
+
+	flush := func() {
+		// Drain a tick that fired meanwhile, or it would flush the next batch
+		// right after its first message.
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if len(batch) == 0 {
+			return
+		}
+		handler(batch)
+		batch = make([]string, 0, size)
+	}
+
+	for {
+		select {
+		case message, ok := <-pc.Messages():
+			if !ok {
+				flush()
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(wait)
+			}
+			batch = append(batch, string(message.Value))
+			if len(batch) >= size {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		case <-c.done:
+			flush()
+			return
+		}
+	}
+}
+
 func (c *Consumer) Stop() {
 	close(c.done)
 }
 
+// Done is closed once the consumer is stopped.
+func (c *Consumer) Done() <-chan struct{} {
+	return c.done
+}
+
 func (c *Consumer) Close() error {
 	c.Stop()
 
@@ -0,0 +1,100 @@
+// Package normalize brings person names arriving in different spellings to a
+// common form. Cyrillic names are transliterated to Latin, so "Иван", "Ivan"
+// and "IVAN " share the same search key.
+package normalize
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Scheme int
+
+const (
+	// ICAO follows ICAO Doc 9303, the scheme used in Russian and Kazakh
+	// passports.
+	ICAO = Scheme(iota)
+	// GOST follows GOST 7.79-2000 system B.
+	GOST
+)
+
+// Kazakh letters are not covered by either standard and are mapped to their
+// closest Latin letters in both schemes.
+var kazakh = map[rune]string{
+	'ә': "a", 'ғ': "g", 'қ': "q", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u", 'һ': "h", 'і': "i",
+}
+
+var schemes = map[Scheme]map[rune]string{
+	ICAO: withKazakh(map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+		'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+		'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh",
+		'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	}),
+	GOST: withKazakh(map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z",
+		'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+		'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh",
+		'щ': "shh", 'ъ': "``", 'ы': "y`", 'ь': "`", 'э': "e`", 'ю': "yu", 'я': "ya",
+	}),
+}
+
+func withKazakh(letters map[rune]string) map[rune]string {
+	for r, latin := range kazakh {
+		letters[r] = latin
+	}
+	return letters
+}
+
+// Clean trims the name and collapses inner whitespace to single spaces.
+func Clean(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Transliterate replaces Cyrillic letters with Latin ones according to the
+// scheme and keeps other characters as is. Upper-case letters stay
+// upper-case: "Жанна" becomes "Zhanna" and "ЖАННА" becomes "ZHANNA".
+func Transliterate(name string, scheme Scheme) string {
+	letters := schemes[scheme]
+	runes := []rune(name)
+
+	var b strings.Builder
+	b.Grow(len(name))
+	for i, r := range runes {
+		latin, ok := letters[unicode.ToLower(r)]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		if !unicode.IsUpper(r) || latin == "" {
+			b.WriteString(latin)
+			continue
+		}
+		if upperWord(runes, i) {
+			b.WriteString(strings.ToUpper(latin))
+			continue
+		}
+		first := []rune(latin)
+		b.WriteRune(unicode.ToUpper(first[0]))
+		b.WriteString(string(first[1:]))
+	}
+	return b.String()
+}
+
+// upperWord tells whether the letter at i sits among other upper-case
+// letters, in which case a multi-letter transliteration is upper-cased as a
+// whole.
+func upperWord(runes []rune, i int) bool {
+	return (i+1 < len(runes) && unicode.IsUpper(runes[i+1])) || (i > 0 && unicode.IsUpper(runes[i-1]))
+}
+
+// Key returns the case-folded ICAO transliteration of the cleaned name.
+func Key(name string) string {
+	return strings.ToLower(Transliterate(Clean(name), ICAO))
+}
+
+// SearchKey is the key of a whole person. Persons whose names differ only
+// in spelling, case or spacing share it.
+func SearchKey(surname string, name string, patronymic string) string {
+	return Key(surname + " " + name + " " + patronymic)
+}
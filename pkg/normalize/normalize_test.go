@@ -0,0 +1,40 @@
+package normalize
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		TestName string
+		Name     string
+		Scheme   Scheme
+		Expected string
+	}{
+		{TestName: "icao", Name: "Щербаков Юрий", Scheme: ICAO, Expected: "Shcherbakov Iurii"},
+		{TestName: "gost", Name: "Щербаков Юрий", Scheme: GOST, Expected: "Shherbakov Yurij"},
+		{TestName: "upper case", Name: "ЖАННА", Scheme: ICAO, Expected: "ZHANNA"},
+		{TestName: "soft sign", Name: "Ольга", Scheme: ICAO, Expected: "Olga"},
+		{TestName: "kazakh letters", Name: "Әлия Құнанбаева", Scheme: ICAO, Expected: "Aliia Qunanbaeva"},
+		{TestName: "latin kept", Name: "Ivan", Scheme: GOST, Expected: "Ivan"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.TestName, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.Expected, Transliterate(tt.Name, tt.Scheme))
+		})
+	}
+}
+
+func TestSearchKey(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "ivanov ivan", SearchKey("Иванов", "Иван", ""))
+	require.Equal(t, SearchKey("Иванов", "Иван", ""), SearchKey(" IVANOV", "Ivan  ", ""))
+	require.Equal(t, "ivanov ivan ivanovich", SearchKey("ivanov", "ivan", "Иванович"))
+}